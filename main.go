@@ -1,12 +1,17 @@
 package main
 
 import (
+	"AshokShau/channelManager/src"
 	"AshokShau/channelManager/src/config"
 	"AshokShau/channelManager/src/db"
 	"AshokShau/channelManager/src/modules"
 	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"time"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -15,7 +20,7 @@ import (
 
 const secretToken = "idkWhatIsThis"
 
-var allowedUpdates = []string{"message", "callback_query", "my_chat_member", "inline_query"}
+var allowedUpdates = []string{"message", "callback_query", "my_chat_member", "inline_query", "message_reaction_count", "channel_post", "edited_channel_post"}
 
 func initBot() (*gotgbot.Bot, *ext.Updater, error) {
 	if config.Token == "" {
@@ -31,9 +36,9 @@ func initBot() (*gotgbot.Bot, *ext.Updater, error) {
 	return bot, updater, nil
 }
 
-func configureWebhook(bot *gotgbot.Bot, updater *ext.Updater) error {
+func configureWebhook(bot *gotgbot.Bot, updater *ext.Updater) (*http.Server, error) {
 	if config.WebhookUrl == "" {
-		return fmt.Errorf("WEBHOOK_URL is not provided")
+		return nil, fmt.Errorf("WEBHOOK_URL is not provided")
 	}
 
 	_, err := bot.SetWebhook(config.WebhookUrl+config.Token, &gotgbot.SetWebhookOpts{
@@ -46,14 +51,47 @@ func configureWebhook(bot *gotgbot.Bot, updater *ext.Updater) error {
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to set webhook: %w", err)
+		return nil, fmt.Errorf("failed to set webhook: %w", err)
 	}
 
-	return updater.StartWebhook(bot, config.Token, ext.WebhookOpts{
-		ListenAddr:  "0.0.0.0:" + config.Port,
-		SecretToken: secretToken,
-		ReadTimeout: 20 * time.Second,
-	})
+	ln, err := net.Listen("tcp", "0.0.0.0:"+config.Port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %s: %w", config.Port, err)
+	}
+
+	if err = updater.AddWebhook(bot, config.Token, &ext.AddWebhookOpts{SecretToken: secretToken}); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	return serveHTTP(bot, ln, updater.GetHandlerFunc("/")), nil
+}
+
+// serveHTTP serves the bot's own endpoints, and the webhook if a handler is given, on the same listener
+func serveHTTP(bot *gotgbot.Bot, ln net.Listener, webhook http.Handler) *http.Server {
+	mux := http.NewServeMux()
+	if webhook != nil {
+		mux.Handle("/", webhook)
+	}
+	modules.RegisterRoutes(mux, bot)
+
+	server := &http.Server{Handler: mux, ReadTimeout: 20 * time.Second}
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server failed: %s", err)
+		}
+	}()
+	return server
+}
+
+// listenHTTP serves the bot's own endpoints when polling, so click tracking and the API work without a webhook
+func listenHTTP(bot *gotgbot.Bot) *http.Server {
+	ln, err := net.Listen("tcp", "0.0.0.0:"+config.Port)
+	if err != nil {
+		log.Printf("Failed to listen on port %s, click tracking and the API are disabled: %s", config.Port, err)
+		return nil
+	}
+	return serveHTTP(bot, ln, nil)
 }
 
 func startPolling(bot *gotgbot.Bot, updater *ext.Updater) error {
@@ -71,18 +109,29 @@ func main() {
 	}
 	
 	mode := "Webhook"
-	if err = configureWebhook(bot, updater); err != nil {
+	server, err := configureWebhook(bot, updater)
+	if err != nil {
 		log.Printf("Webhook configuration failed: %s", err)
 		mode = "Polling"
 		if err = startPolling(bot, updater); err != nil {
 			log.Fatalf("Polling start failed: %s", err)
 		}
+		server = listenHTTP(bot)
 	}
 
+	modules.StartWorkers(bot)
+	src.SetMyCommands(bot)
 	log.Printf("Bot has been started as %s[%s] using %s", bot.FirstName, bot.Username, mode)
 
 	updater.Idle()
 	log.Printf("Bot has been stopped")
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err = server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP server shutdown failed: %s", err)
+		}
+		cancel()
+	}
 	db.Close()
 	onlyAdmins.CloseRedis()
 	log.Printf("Bye!")
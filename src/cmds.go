@@ -1,23 +1,131 @@
 package src
 
 import (
+	"AshokShau/channelManager/src/config"
 	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"log"
+	"strings"
+
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
 )
 
-// AddCommand adds multiple commands with alias support and checks if the user is banned before processing
-func AddCommand(dispatcher *ext.Dispatcher, aliases []string, r handlers.Response) {
-	for _, alias := range aliases {
-		originalResponse := r
+// Scope is the command menu a command is listed in
+type Scope int
+
+const (
+	// ScopePrivate lists a command in the menu of all private chats
+	ScopePrivate Scope = iota
+	// ScopeOwner lists a command only in the menu of the owner's private chat
+	ScopeOwner
+	// ScopeHidden keeps a command out of the menus, it is still in the help
+	ScopeHidden
+)
+
+// Permission is who may use a command
+type Permission int
+
+const (
+	PermEveryone Permission = iota
+	PermOwner
+)
+
+// Sections of the help, in the order they are shown
+var (
+	SectionGeneral    = i18n.N("General commands")
+	SectionConnection = i18n.N("Connection commands")
+	SectionPost       = i18n.N("Post commands")
+	SectionSettings   = i18n.N("User settings")
+	SectionOwner      = i18n.N("Owner commands")
+
+	Sections = []string{SectionGeneral, SectionConnection, SectionPost, SectionSettings, SectionOwner}
+)
+
+// Command describes a command for the help and the command menus. Names[0] is the main name and the others
+// are aliases, Usage lists the arguments after the name. Description and Section are English messages that
+// are translated when shown.
+type Command struct {
+	Names       []string
+	Usage       string
+	Description string
+	Section     string
+	Scope       Scope
+	Permission  Permission
+}
+
+// Commands are the registered commands in the order they were added
+var Commands []Command
+
+// Register records a command for the help and the menus without adding a handler, for commands that are
+// handled elsewhere like the entry of a conversation
+func Register(cmd Command) {
+	Commands = append(Commands, cmd)
+}
+
+// AddCommand registers a command and adds a handler for each of its names. Banned users are ignored and owner
+// commands are refused to everyone else.
+func AddCommand(dispatcher *ext.Dispatcher, cmd Command, r handlers.Response) {
+	Register(cmd)
+	for _, alias := range cmd.Names {
 		command := handlers.NewCommand(alias, func(b *gotgbot.Bot, ctx *ext.Context) error {
 			if db.IsUserBanned(ctx.EffectiveUser.Id) {
 				return nil
 			}
-			return originalResponse(b, ctx)
+			if cmd.Permission == PermOwner && ctx.EffectiveUser.Id != config.OwnerId {
+				if ctx.EffectiveChat.Type == "private" {
+					_, _ = ctx.EffectiveMessage.Reply(b, i18n.T(i18n.Lang(ctx.EffectiveUser), "You must be the owner to use this command."), nil)
+				}
+				return nil
+			}
+			return r(b, ctx)
 		})
 		command.Triggers = []rune{'/', '!'}
 		dispatcher.AddHandler(command)
 	}
 }
+
+// FindCommand returns the command with a name or alias, which may start with / or !
+func FindCommand(name string) (Command, bool) {
+	name = strings.ToLower(strings.TrimLeft(name, "/!"))
+	for _, cmd := range Commands {
+		for _, alias := range cmd.Names {
+			if strings.ToLower(alias) == name {
+				return cmd, true
+			}
+		}
+	}
+	return Command{}, false
+}
+
+// SetMyCommands sets the command menus of private chats and of the owner's chat, once for each language
+func SetMyCommands(b *gotgbot.Bot) {
+	for _, lang := range i18n.Languages() {
+		code := lang
+		if lang == i18n.Default {
+			// Users whose language has no catalog get the default menu
+			code = ""
+		}
+
+		var private, owner []gotgbot.BotCommand
+		for _, cmd := range Commands {
+			if cmd.Scope == ScopeHidden {
+				continue
+			}
+
+			command := gotgbot.BotCommand{Command: strings.ToLower(cmd.Names[0]), Description: i18n.T(lang, cmd.Description)}
+			if cmd.Scope == ScopePrivate {
+				private = append(private, command)
+			}
+			owner = append(owner, command)
+		}
+
+		if _, err := b.SetMyCommands(private, &gotgbot.SetMyCommandsOpts{Scope: gotgbot.BotCommandScopeAllPrivateChats{}, LanguageCode: code}); err != nil {
+			log.Printf("SetMyCommands: failed to set the private commands for %s: %v", lang, err)
+		}
+		if _, err := b.SetMyCommands(owner, &gotgbot.SetMyCommandsOpts{Scope: gotgbot.BotCommandScopeChat{ChatId: config.OwnerId}, LanguageCode: code}); err != nil {
+			log.Printf("SetMyCommands: failed to set the owner commands for %s: %v", lang, err)
+		}
+	}
+}
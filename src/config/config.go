@@ -18,6 +18,9 @@ var (
 
 	WebhookUrl = getEnv("WEBHOOK_URL", "")
 	Port       = getEnv("PORT", "9099")
+
+	// ClickTrackingUrl is the public base url of the HTTP listener on PORT, tracked button links are disabled if it is empty
+	ClickTrackingUrl = getEnv("CLICK_TRACKING_URL", "")
 )
 
 // getEnv returns the value of an environment variable or a default value if it is not set
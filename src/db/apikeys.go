@@ -0,0 +1,50 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ApiKey is an API key of a user, only the SHA-256 hash of the key is stored
+type ApiKey struct {
+	Hash    string `bson:"_id" json:"-"`
+	UserId  int64  `bson:"user_id" json:"user_id"`
+	Created int64  `bson:"created" json:"created"`
+}
+
+// SetApiKey stores the API key of a user, replacing the previous one
+func SetApiKey(key ApiKey) error {
+	if err := RemoveApiKeys(key.UserId); err != nil {
+		return err
+	}
+	if _, err := apiKeysColl.InsertOne(ctx, key); err != nil {
+		log.Printf("[Database] SetApiKey: %v - %d", err, key.UserId)
+		return err
+	}
+	return nil
+}
+
+// GetApiKey retrieves an API key by its hash, nil if it doesn't exist
+func GetApiKey(hash string) (*ApiKey, error) {
+	var key ApiKey
+	err := findOne(apiKeysColl, bson.M{"_id": hash}).Decode(&key)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RemoveApiKeys revokes the API keys of a user
+func RemoveApiKeys(userID int64) error {
+	if _, err := apiKeysColl.DeleteMany(ctx, bson.M{"user_id": userID}); err != nil {
+		log.Printf("[Database] RemoveApiKeys: %v - %d", err, userID)
+		return err
+	}
+	return nil
+}
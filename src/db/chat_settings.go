@@ -0,0 +1,186 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ChatSettings holds a user's settings for one of their connected chats
+type ChatSettings struct {
+	UserId  int64    `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	ChatId  int64    `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
+	Footer  string   `bson:"footer,omitempty" json:"footer,omitempty"`
+	Buttons []Button `bson:"buttons,omitempty" json:"buttons,omitempty"`
+
+	Watermark *Watermark       `bson:"watermark,omitempty" json:"watermark,omitempty"`
+	Overrides SettingOverrides `bson:"overrides,omitempty" json:"overrides,omitempty"`
+}
+
+// SettingOverrides replace a user's settings in one chat, nil fields keep the user's setting
+type SettingOverrides struct {
+	NoNotif      *bool `bson:"nonotif,omitempty" json:"nonotif,omitempty"`
+	Protect      *bool `bson:"protect,omitempty" json:"protect,omitempty"`
+	Spoiler      *bool `bson:"spoiler,omitempty" json:"spoiler,omitempty"`
+	WebPreview   *bool `bson:"webpreview,omitempty" json:"webpreview,omitempty"`
+	CaptionAbove *bool `bson:"captionabove,omitempty" json:"captionabove,omitempty"`
+	ForwardTag   *bool `bson:"forwardtag,omitempty" json:"forwardtag,omitempty"`
+}
+
+// Apply returns a copy of the user's settings with the overrides applied, followed by the options of the delivery
+func (o SettingOverrides) Apply(settings *UserSettings) *UserSettings {
+	effective := *settings
+	o.apply(&effective)
+	if settings.Options != nil {
+		settings.Options.apply(&effective)
+	}
+	return &effective
+}
+
+func (o SettingOverrides) apply(settings *UserSettings) {
+	for _, field := range []struct {
+		override *bool
+		setting  *bool
+	}{
+		{o.NoNotif, &settings.NoNotif},
+		{o.Protect, &settings.Protect},
+		{o.Spoiler, &settings.Spoiler},
+		{o.WebPreview, &settings.WebPreview},
+		{o.CaptionAbove, &settings.CaptionAbove},
+		{o.ForwardTag, &settings.ForwardTag},
+	} {
+		if field.override != nil {
+			*field.setting = *field.override
+		}
+	}
+}
+
+// Merge returns the overrides with the fields set in other replacing them, nil if neither has any
+func (o *SettingOverrides) Merge(other *SettingOverrides) *SettingOverrides {
+	if o == nil {
+		return other
+	}
+	merged := *o
+	if other != nil {
+		for _, field := range []struct {
+			override *bool
+			merged   **bool
+		}{
+			{other.NoNotif, &merged.NoNotif},
+			{other.Protect, &merged.Protect},
+			{other.Spoiler, &merged.Spoiler},
+			{other.WebPreview, &merged.WebPreview},
+			{other.CaptionAbove, &merged.CaptionAbove},
+			{other.ForwardTag, &merged.ForwardTag},
+		} {
+			if field.override != nil {
+				*field.merged = field.override
+			}
+		}
+	}
+	return &merged
+}
+
+// EffectiveSettings returns the settings a user's posts are sent with in a chat
+func EffectiveSettings(settings *UserSettings, chatID int64) *UserSettings {
+	if settings == nil {
+		return nil
+	}
+	return GetChatSettings(settings.UserId, chatID).Overrides.Apply(settings)
+}
+
+// Watermark positions
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// Watermark is an image stamped on photo posts before they are sent to a chat
+type Watermark struct {
+	FileId   string `bson:"file_id" json:"file_id"`
+	Position string `bson:"position" json:"position"`
+	Opacity  int    `bson:"opacity" json:"opacity"` // percent, 1-100
+}
+
+// GetChatSettings retrieves a user's settings for a chat or returns empty settings if not found.
+func GetChatSettings(userID, chatID int64) *ChatSettings {
+	settings := &ChatSettings{UserId: userID, ChatId: chatID}
+	if err := findOne(chatSettingsColl, bson.M{"user_id": userID, "chat_id": chatID}).Decode(settings); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("[Database] GetChatSettings: %d - %d - %v", userID, chatID, err)
+		}
+	}
+	return settings
+}
+
+// SetChatFooter updates the footer and default buttons that are added to posts in a chat.
+func SetChatFooter(userID, chatID int64, footer string, buttons []Button) error {
+	filter := bson.M{"user_id": userID, "chat_id": chatID}
+	update := bson.M{"$set": bson.M{"footer": footer, "buttons": buttons}}
+	if footer == "" && len(buttons) == 0 {
+		update = bson.M{"$unset": bson.M{"footer": "", "buttons": ""}}
+	}
+
+	if _, err := chatSettingsColl.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		log.Printf("[Database] SetChatFooter: %v - %d - %d", err, userID, chatID)
+		return err
+	}
+	return nil
+}
+
+// SetChatWatermark updates the watermark of a chat, a nil watermark removes it.
+func SetChatWatermark(userID, chatID int64, watermark *Watermark) error {
+	filter := bson.M{"user_id": userID, "chat_id": chatID}
+	update := bson.M{"$set": bson.M{"watermark": watermark}}
+	if watermark == nil {
+		update = bson.M{"$unset": bson.M{"watermark": ""}}
+	}
+
+	if _, err := chatSettingsColl.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		log.Printf("[Database] SetChatWatermark: %v - %d - %d", err, userID, chatID)
+		return err
+	}
+	return nil
+}
+
+// SetChatOverride overrides one user setting in a chat, a nil value goes back to the user's setting.
+// An empty field removes all overrides of the chat.
+func SetChatOverride(userID, chatID int64, field string, value *bool) error {
+	filter := bson.M{"user_id": userID, "chat_id": chatID}
+	var update bson.M
+	switch {
+	case field == "":
+		update = bson.M{"$unset": bson.M{"overrides": ""}}
+	case value == nil:
+		update = bson.M{"$unset": bson.M{"overrides." + field: ""}}
+	default:
+		update = bson.M{"$set": bson.M{"overrides." + field: *value}}
+	}
+
+	if _, err := chatSettingsColl.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		log.Printf("[Database] SetChatOverride: %v - %d - %d", err, userID, chatID)
+		return err
+	}
+	return nil
+}
+
+// ListChatSettings retrieves the settings of all chats of a user
+func ListChatSettings(userID int64) ([]ChatSettings, error) {
+	cursor, err := find(chatSettingsColl, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var settings []ChatSettings
+	if err = cursor.All(ctx, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
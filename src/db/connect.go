@@ -93,3 +93,25 @@ func DisconnectAll(userID int64) {
 		log.Printf("[Database] DisconnectAll: %v - %d", err, userID)
 	}
 }
+
+// ConnectedUsers returns the ids of all users connected to a chat.
+func ConnectedUsers(chatID int64) ([]int64, error) {
+	cursor, err := find(connectionColl, bson.M{"chat_ids": chatID})
+	if err != nil {
+		log.Printf("[Database] ConnectedUsers: %v - %d", err, chatID)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var connections []Connections
+	if err = cursor.All(ctx, &connections); err != nil {
+		log.Printf("[Database] ConnectedUsers: %v - %d", err, chatID)
+		return nil, err
+	}
+
+	userIds := make([]int64, 0, len(connections))
+	for _, connection := range connections {
+		userIds = append(userIds, connection.UserId)
+	}
+	return userIds, nil
+}
@@ -11,10 +11,20 @@ import (
 
 type Button struct {
 	Name     string `bson:"name,omitempty" json:"name,omitempty"`
+	Type     string `bson:"type,omitempty" json:"type,omitempty"`
 	Url      string `bson:"url,omitempty" json:"url,omitempty"`
 	SameLine bool   `bson:"btn_sameline,omitempty" json:"btn_sameline,omitempty"`
 }
 
+// Constants for Button Types, an empty type is treated as ButtonUrl
+const (
+	ButtonUrl   = "url"
+	ButtonReact = "react"
+	ButtonAlert = "alert"
+	ButtonShare = "share"
+	ButtonCopy  = "copy"
+)
+
 // Constants for Media Types
 const (
 	TEXT      = 1
@@ -33,6 +43,10 @@ var (
 	ctx                                           = context.TODO()
 	mongoClient                                   *mongo.Client
 	bansColl, usersColl, connectionColl, postColl *mongo.Collection
+	votesColl, linksColl, reactionsColl           *mongo.Collection
+	chatSettingsColl, policiesColl, unpinsColl    *mongo.Collection
+	mirrorsColl, groupsColl, feedsColl            *mongo.Collection
+	apiKeysColl, schedulesColl, webhooksColl      *mongo.Collection
 )
 
 // Initialization Function
@@ -52,6 +66,27 @@ func init() {
 	connectionColl = db.Collection("connections")
 	postColl = db.Collection("post")
 	bansColl = db.Collection("bans")
+	votesColl = db.Collection("votes")
+	linksColl = db.Collection("links")
+	reactionsColl = db.Collection("reactions")
+	chatSettingsColl = db.Collection("chat_settings")
+	policiesColl = db.Collection("policies")
+	unpinsColl = db.Collection("unpins")
+	mirrorsColl = db.Collection("mirrors")
+	groupsColl = db.Collection("groups")
+	feedsColl = db.Collection("feeds")
+	apiKeysColl = db.Collection("api_keys")
+	schedulesColl = db.Collection("schedules")
+	webhooksColl = db.Collection("webhooks")
+
+	// A user has one vote per post message, the index also keeps concurrent taps from adding a second one
+	_, err = votesColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "chat_id", Value: 1}, {Key: "msg_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Printf("[Database][Index] votes: %v", err)
+	}
 }
 
 // Close MongoDB Connection
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxSeenItems is the number of item GUIDs remembered per feed, more than feeds usually list
+const maxSeenItems = 500
+
+// Feed is an RSS or Atom subscription whose new items are posted to the user's chats
+type Feed struct {
+	FeedId   string   `bson:"_id" json:"feed_id"`
+	UserId   int64    `bson:"user_id" json:"user_id"`
+	Url      string   `bson:"url" json:"url"`
+	Title    string   `bson:"title,omitempty" json:"title,omitempty"`
+	Group    string   `bson:"group,omitempty" json:"group,omitempty"`       // empty means all connected chats
+	Template string   `bson:"template,omitempty" json:"template,omitempty"` // empty uses the default template
+	Seen     []string `bson:"seen,omitempty" json:"seen,omitempty"`         // GUIDs of the items already handled
+	Error    string   `bson:"error,omitempty" json:"error,omitempty"`       // error of the last poll
+}
+
+// AddFeed stores a new feed subscription
+func AddFeed(feed *Feed) error {
+	if _, err := feedsColl.InsertOne(ctx, feed); err != nil {
+		log.Printf("[Database] AddFeed: %v - %d - %s", err, feed.UserId, feed.Url)
+		return err
+	}
+	return nil
+}
+
+// GetFeed retrieves a feed by its id, nil if it doesn't exist
+func GetFeed(feedID string) (*Feed, error) {
+	var feed Feed
+	err := findOne(feedsColl, bson.M{"_id": feedID}).Decode(&feed)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// RemoveFeed deletes a feed subscription
+func RemoveFeed(feedID string) error {
+	if err := deleteOne(feedsColl, bson.M{"_id": feedID}); err != nil {
+		log.Printf("[Database] RemoveFeed: %v - %s", err, feedID)
+		return err
+	}
+	return nil
+}
+
+// SetFeedTemplate sets the template feed items are rendered with, empty restores the default
+func SetFeedTemplate(feedID, template string) error {
+	_, err := feedsColl.UpdateOne(ctx, bson.M{"_id": feedID}, bson.M{"$set": bson.M{"template": template}})
+	if err != nil {
+		log.Printf("[Database] SetFeedTemplate: %v - %s", err, feedID)
+	}
+	return err
+}
+
+// MarkFeedItems records the result of a poll: the GUIDs of the handled items and the poll error
+func MarkFeedItems(feedID string, guids []string, pollErr string) error {
+	update := bson.M{"$set": bson.M{"error": pollErr}}
+	if len(guids) > 0 {
+		update["$push"] = bson.M{"seen": bson.M{"$each": guids, "$slice": -maxSeenItems}}
+	}
+
+	_, err := feedsColl.UpdateOne(ctx, bson.M{"_id": feedID}, update)
+	if err != nil {
+		log.Printf("[Database] MarkFeedItems: %v - %s", err, feedID)
+	}
+	return err
+}
+
+// ListFeeds retrieves the feeds of a user
+func ListFeeds(userID int64) ([]Feed, error) {
+	return findFeeds(bson.M{"user_id": userID})
+}
+
+// AllFeeds retrieves every feed subscription for the poller
+func AllFeeds() ([]Feed, error) {
+	return findFeeds(bson.M{})
+}
+
+func findFeeds(filter bson.M) ([]Feed, error) {
+	cursor, err := find(feedsColl, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var feeds []Feed
+	if err = cursor.All(ctx, &feeds); err != nil {
+		return nil, err
+	}
+	return feeds, nil
+}
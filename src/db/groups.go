@@ -0,0 +1,63 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ChatGroup is a named set of connected chats that automated posts can be sent to
+type ChatGroup struct {
+	UserId  int64   `bson:"user_id" json:"user_id"`
+	Name    string  `bson:"name" json:"name"`
+	ChatIds []int64 `bson:"chat_ids" json:"chat_ids"`
+}
+
+// SetChatGroup creates or replaces a chat group of the user
+func SetChatGroup(userID int64, name string, chatIDs []int64) error {
+	group := ChatGroup{UserId: userID, Name: name, ChatIds: chatIDs}
+	if err := updateOne(groupsColl, bson.M{"user_id": userID, "name": name}, group); err != nil {
+		log.Printf("[Database] SetChatGroup: %v - %d - %s", err, userID, name)
+		return err
+	}
+	return nil
+}
+
+// RemoveChatGroup deletes a chat group of the user
+func RemoveChatGroup(userID int64, name string) error {
+	if err := deleteOne(groupsColl, bson.M{"user_id": userID, "name": name}); err != nil {
+		log.Printf("[Database] RemoveChatGroup: %v - %d - %s", err, userID, name)
+		return err
+	}
+	return nil
+}
+
+// GetChatGroup retrieves a chat group by its name, nil if it doesn't exist
+func GetChatGroup(userID int64, name string) (*ChatGroup, error) {
+	var group ChatGroup
+	err := findOne(groupsColl, bson.M{"user_id": userID, "name": name}).Decode(&group)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+// ListChatGroups retrieves the chat groups of a user
+func ListChatGroups(userID int64) ([]ChatGroup, error) {
+	cursor, err := find(groupsColl, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []ChatGroup
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Link represents a tracked button link of a post in a chat
+type Link struct {
+	Token  string `bson:"_id,omitempty" json:"token,omitempty"`
+	PostId string `bson:"post_id,omitempty" json:"post_id,omitempty"`
+	ChatId int64  `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
+	Button int    `bson:"button" json:"button"`
+	Name   string `bson:"name,omitempty" json:"name,omitempty"`
+	Url    string `bson:"url,omitempty" json:"url,omitempty"`
+	Clicks int64  `bson:"clicks,omitempty" json:"clicks,omitempty"`
+}
+
+// AddLink stores a new tracked link
+func AddLink(link Link) error {
+	if _, err := linksColl.InsertOne(ctx, link); err != nil {
+		log.Printf("[Database] AddLink: %v - PostId: %s, Chat: %d", err, link.PostId, link.ChatId)
+		return err
+	}
+	return nil
+}
+
+// GetLink retrieves a tracked link by its token
+func GetLink(token string) (*Link, error) {
+	var link Link
+	err := findOne(linksColl, bson.M{"_id": token}).Decode(&link)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Return nil if no link found
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// AddClick records a click on a tracked link
+func AddClick(token string) {
+	_, err := linksColl.UpdateOne(ctx, bson.M{"_id": token}, bson.M{"$inc": bson.M{"clicks": 1}})
+	if err != nil {
+		log.Printf("[Database] AddClick: %v - Token: %s", err, token)
+	}
+}
+
+// ListLinks retrieves all tracked links of a post
+func ListLinks(postID string) ([]Link, error) {
+	cursor, err := find(linksColl, bson.M{"post_id": postID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var links []Link
+	if err = cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Mirror copies every new post of a source chat to other chats of the user
+type Mirror struct {
+	UserId   int64   `bson:"user_id" json:"user_id"`
+	SourceId int64   `bson:"source_id" json:"source_id"`
+	Targets  []int64 `bson:"targets,omitempty" json:"targets,omitempty"` // empty means all connected chats
+}
+
+// SetMirror creates or updates the mirror of a source chat
+func SetMirror(userID, sourceID int64, targets []int64) error {
+	mirror := Mirror{UserId: userID, SourceId: sourceID, Targets: targets}
+	if err := updateOne(mirrorsColl, bson.M{"user_id": userID, "source_id": sourceID}, mirror); err != nil {
+		log.Printf("[Database] SetMirror: %v - %d - %d", err, userID, sourceID)
+		return err
+	}
+	return nil
+}
+
+// RemoveMirror deletes the mirror of a source chat
+func RemoveMirror(userID, sourceID int64) error {
+	if err := deleteOne(mirrorsColl, bson.M{"user_id": userID, "source_id": sourceID}); err != nil {
+		log.Printf("[Database] RemoveMirror: %v - %d - %d", err, userID, sourceID)
+		return err
+	}
+	return nil
+}
+
+// GetMirrors retrieves the mirrors of a source chat
+func GetMirrors(sourceID int64) ([]Mirror, error) {
+	return findMirrors(bson.M{"source_id": sourceID})
+}
+
+// ListMirrors retrieves the mirrors of a user
+func ListMirrors(userID int64) ([]Mirror, error) {
+	return findMirrors(bson.M{"user_id": userID})
+}
+
+func findMirrors(filter bson.M) ([]Mirror, error) {
+	cursor, err := find(mirrorsColl, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var mirrors []Mirror
+	if err = cursor.All(ctx, &mirrors); err != nil {
+		return nil, err
+	}
+	return mirrors, nil
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Policy holds the content checks that run before a post is sent.
+// A policy with ChatId 0 applies to all chats of the user, others only to their chat.
+type Policy struct {
+	UserId       int64    `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	ChatId       int64    `bson:"chat_id" json:"chat_id"`
+	BannedWords  []string `bson:"banned_words,omitempty" json:"banned_words,omitempty"`
+	AllowDomains []string `bson:"allow_domains,omitempty" json:"allow_domains,omitempty"`
+	DenyDomains  []string `bson:"deny_domains,omitempty" json:"deny_domains,omitempty"`
+	MaxLinks     *int     `bson:"max_links,omitempty" json:"max_links,omitempty"`
+	RequiredTags []string `bson:"required_tags,omitempty" json:"required_tags,omitempty"`
+	// Confirm lets the user send anyway after reviewing the violations instead of blocking the send
+	Confirm bool `bson:"confirm,omitempty" json:"confirm,omitempty"`
+}
+
+// GetPolicy retrieves the policy of a user for a chat, or nil if there is none.
+func GetPolicy(userID, chatID int64) *Policy {
+	policy := &Policy{}
+	if err := findOne(policiesColl, bson.M{"user_id": userID, "chat_id": chatID}).Decode(policy); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("[Database] GetPolicy: %d - %d - %v", userID, chatID, err)
+		}
+		return nil
+	}
+	return policy
+}
+
+// SetPolicyField sets one field of a policy, a nil value removes it.
+func SetPolicyField(userID, chatID int64, field string, value interface{}) error {
+	filter := bson.M{"user_id": userID, "chat_id": chatID}
+	update := bson.M{"$set": bson.M{field: value}}
+	if value == nil {
+		update = bson.M{"$unset": bson.M{field: ""}}
+	}
+
+	if _, err := policiesColl.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		log.Printf("[Database] SetPolicyField: %v - %d - %d - %s", err, userID, chatID, field)
+		return err
+	}
+	return nil
+}
+
+// RemovePolicy deletes the policy of a user for a chat
+func RemovePolicy(userID, chatID int64) error {
+	if err := deleteOne(policiesColl, bson.M{"user_id": userID, "chat_id": chatID}); err != nil {
+		log.Printf("[Database] RemovePolicy: %v - %d - %d", err, userID, chatID)
+		return err
+	}
+	return nil
+}
@@ -11,21 +11,50 @@ import (
 
 // Chat represents a chat with a single MsgId
 type Chat struct {
-	ChatId int64 `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
-	MsgId  int64 `bson:"msg_id,omitempty" json:"msg_id,omitempty"`
+	ChatId  int64 `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
+	MsgId   int64 `bson:"msg_id,omitempty" json:"msg_id,omitempty"`
+	Comment bool  `bson:"comment,omitempty" json:"comment,omitempty"` // the message is the post's comment in a discussion group
 }
 
 // Post represents a post document in MongoDB
 type Post struct {
-	PostId      string   `bson:"_id,omitempty" json:"post_id,omitempty"`
-	UserId      int64    `bson:"user_id,omitempty" json:"user_id,omitempty"`
-	MsgType     int      `bson:"msgtype,omitempty" json:"msgtype,omitempty"`
-	Chats       []Chat   `bson:"chats,omitempty" json:"chats,omitempty"`
+	PostId      string            `bson:"_id,omitempty" json:"post_id,omitempty"`
+	UserId      int64             `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	MsgType     int               `bson:"msgtype,omitempty" json:"msgtype,omitempty"`
+	Chats       []Chat            `bson:"chats,omitempty" json:"chats,omitempty"`
+	FileID      string            `bson:"fileid,omitempty" json:"fileid,omitempty"`
+	Buttons     []Button          `bson:"buttons,omitempty" json:"buttons,omitempty"`
+	FilterReply string            `bson:"reply,omitempty" json:"reply,omitempty"`
+	Comment     string            `bson:"comment,omitempty" json:"comment,omitempty"`
+	Pin         *PinOptions       `bson:"pin,omitempty" json:"pin,omitempty"`
+	Source      *Chat             `bson:"source,omitempty" json:"source,omitempty"` // the channel post a mirrored post was copied from
+	Versions    []PostVersion     `bson:"versions,omitempty" json:"versions,omitempty"`
+	Options     *SettingOverrides `bson:"options,omitempty" json:"options,omitempty"` // flags the post was sent with, they override the user's settings
+}
+
+// maxPostVersions is the number of earlier versions kept for a post
+const maxPostVersions = 20
+
+// PostVersion is the content a post had before it was edited
+type PostVersion struct {
+	Date        int64    `bson:"date" json:"date"` // unix time of the edit that replaced this version
+	MsgType     int      `bson:"msgtype" json:"msgtype"`
 	FileID      string   `bson:"fileid,omitempty" json:"fileid,omitempty"`
 	Buttons     []Button `bson:"buttons,omitempty" json:"buttons,omitempty"`
 	FilterReply string   `bson:"reply,omitempty" json:"reply,omitempty"`
 }
 
+// Version returns the current content of the post as a version replaced at the given time
+func (p *Post) Version(date int64) PostVersion {
+	return PostVersion{Date: date, MsgType: p.MsgType, FileID: p.FileID, Buttons: p.Buttons, FilterReply: p.FilterReply}
+}
+
+// PinOptions control how a post is pinned in the chats it is sent to
+type PinOptions struct {
+	Silent     bool  `bson:"silent,omitempty" json:"silent,omitempty"`
+	UnpinAfter int64 `bson:"unpin_after,omitempty" json:"unpin_after,omitempty"` // seconds, 0 keeps the post pinned
+}
+
 // GetPost retrieves a post by its PostId
 func GetPost(postID string) (*Post, error) {
 	var post Post
@@ -39,6 +68,52 @@ func GetPost(postID string) (*Post, error) {
 	return &post, nil
 }
 
+// GetPostByMessage retrieves the latest post that was delivered as msgID in chatID
+func GetPostByMessage(chatID, msgID int64) (*Post, error) {
+	var post Post
+	filter := bson.M{"chats": bson.M{"$elemMatch": bson.M{"chat_id": chatID, "msg_id": msgID}}}
+	err := postColl.FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"_id": -1})).Decode(&post)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil // Return nil if no post found
+		}
+		return nil, err
+	}
+	return &post, nil
+}
+
+// GetPostsBySource retrieves all posts mirrored from msgID in chatID, one per mirror
+func GetPostsBySource(chatID, msgID int64) ([]Post, error) {
+	cursor, err := find(postColl, bson.M{"source.chat_id": chatID, "source.msg_id": msgID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []Post
+	if err = cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// ListChatPosts retrieves the posts of a user that were delivered to chatID
+func ListChatPosts(userID, chatID int64) ([]Post, error) {
+	cursor, err := find(postColl, bson.M{"user_id": userID, "chats.chat_id": chatID})
+	if err != nil {
+		log.Printf("[Database] ListChatPosts: Failed to retrieve posts of chat %d for User %d: %v", chatID, userID, err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []Post
+	if err = cursor.All(ctx, &posts); err != nil {
+		log.Printf("[Database] ListChatPosts: Error decoding posts of chat %d for User %d - %v", chatID, userID, err)
+		return nil, err
+	}
+	return posts, nil
+}
+
 // RemovePost removes a post by its PostId
 func RemovePost(postID string) error {
 	//if err := deleteOne(postColl, bson.M{"_id": postID}); err != nil {
@@ -81,6 +156,98 @@ func AddPost(postID string, userID, chatID, msgID int64, msgType int, fileID str
 	return postID, nil
 }
 
+// SetPostContent creates a post or replaces its content, without touching the chats it was sent to
+func SetPostContent(postID string, userID int64, msgType int, fileID string, buttons []Button, filterReply string) error {
+	update := bson.M{"$set": bson.M{
+		"user_id": userID,
+		"msgtype": msgType,
+		"fileid":  fileID,
+		"buttons": buttons,
+		"reply":   filterReply,
+	}}
+
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Printf("[Database] SetPostContent: %v - PostId: %s, User: %d", err, postID, userID)
+	}
+	return err
+}
+
+// ReplacePost stores a complete post, replacing the post with the same PostId
+func ReplacePost(post Post) error {
+	_, err := postColl.ReplaceOne(ctx, bson.M{"_id": post.PostId}, post, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("[Database] ReplacePost: %v - PostId: %s", err, post.PostId)
+	}
+	return err
+}
+
+// SetPostComment sets the comment that is posted under the post in linked discussion groups
+func SetPostComment(postID, comment string) error {
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$set": bson.M{"comment": comment}})
+	if err != nil {
+		log.Printf("[Database] SetPostComment: %v - PostId: %s", err, postID)
+	}
+	return err
+}
+
+// SetPostPin sets how the post is pinned when it is sent, nil stops pinning it
+func SetPostPin(postID string, pin *PinOptions) error {
+	update := bson.M{"$set": bson.M{"pin": pin}}
+	if pin == nil {
+		update = bson.M{"$unset": bson.M{"pin": ""}}
+	}
+
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, update)
+	if err != nil {
+		log.Printf("[Database] SetPostPin: %v - PostId: %s", err, postID)
+	}
+	return err
+}
+
+// SetPostOptions sets the options a post is sent with, nil removes them
+func SetPostOptions(postID string, options *SettingOverrides) error {
+	update := bson.M{"$set": bson.M{"options": options}}
+	if options == nil {
+		update = bson.M{"$unset": bson.M{"options": ""}}
+	}
+
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, update)
+	if err != nil {
+		log.Printf("[Database] SetPostOptions: %v - PostId: %s", err, postID)
+	}
+	return err
+}
+
+// SetPostSource records the channel post a mirrored post was copied from
+func SetPostSource(postID string, chatID, msgID int64) error {
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$set": bson.M{"source": Chat{ChatId: chatID, MsgId: msgID}}})
+	if err != nil {
+		log.Printf("[Database] SetPostSource: %v - PostId: %s, Chat: %d, Msg: %d", err, postID, chatID, msgID)
+	}
+	return err
+}
+
+// AddPostVersions appends earlier versions to the history of a post, keeping the latest maxPostVersions
+func AddPostVersions(postID string, versions ...PostVersion) error {
+	update := bson.M{"$push": bson.M{"versions": bson.M{"$each": versions, "$slice": -maxPostVersions}}}
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, update)
+	if err != nil {
+		log.Printf("[Database] AddPostVersions: %v - PostId: %s", err, postID)
+	}
+	return err
+}
+
+// AddPostComment records a comment message of the post in a discussion group
+func AddPostComment(postID string, chatID, msgID int64) error {
+	chat := Chat{ChatId: chatID, MsgId: msgID, Comment: true}
+	_, err := postColl.UpdateOne(ctx, bson.M{"_id": postID}, bson.M{"$addToSet": bson.M{"chats": chat}})
+	if err != nil {
+		log.Printf("[Database] AddPostComment: %v - PostId: %s, Chat: %d, Msg: %d", err, postID, chatID, msgID)
+	}
+	return err
+}
+
 // ListPosts retrieves all posts for a user
 func ListPosts(userID int64) ([]Post, error) {
 	var posts []Post
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MessageReactions holds the latest reaction totals of a delivered post message
+type MessageReactions struct {
+	PostId    string           `bson:"post_id,omitempty" json:"post_id,omitempty"`
+	ChatId    int64            `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
+	MsgId     int64            `bson:"msg_id,omitempty" json:"msg_id,omitempty"`
+	Reactions map[string]int64 `bson:"reactions" json:"reactions"`
+	Date      int64            `bson:"date,omitempty" json:"date,omitempty"`
+}
+
+// SetMessageReactions replaces the reaction totals of a post message.
+// Updates older than the stored ones are ignored, as Telegram may deliver them out of order.
+func SetMessageReactions(postID string, chatID, msgID int64, reactions map[string]int64, date int64) error {
+	filter := bson.M{"chat_id": chatID, "msg_id": msgID}
+
+	var current MessageReactions
+	err := findOne(reactionsColl, filter).Decode(&current)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if err == nil && current.Date > date {
+		return nil
+	}
+
+	update := MessageReactions{PostId: postID, ChatId: chatID, MsgId: msgID, Reactions: reactions, Date: date}
+	if err = updateOne(reactionsColl, filter, update); err != nil {
+		log.Printf("[Database] SetMessageReactions: %v - Chat: %d, Msg: %d", err, chatID, msgID)
+		return err
+	}
+	return nil
+}
+
+// GetPostReactions retrieves the reaction totals of the given post messages
+func GetPostReactions(chats []Chat) ([]MessageReactions, error) {
+	if len(chats) == 0 {
+		return nil, nil
+	}
+
+	messages := make(bson.A, 0, len(chats))
+	for _, chat := range chats {
+		messages = append(messages, bson.M{"chat_id": chat.ChatId, "msg_id": chat.MsgId})
+	}
+
+	cursor, err := find(reactionsColl, bson.M{"$or": messages})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reactions []MessageReactions
+	if err = cursor.All(ctx, &reactions); err != nil {
+		return nil, err
+	}
+	return reactions, nil
+}
+
+// GetChatReactions sums the reaction totals of all tracked post messages in a chat.
+// It returns the totals per reaction and the number of messages that were counted.
+func GetChatReactions(chatID int64) (map[string]int64, int64, error) {
+	messages, err := reactionsColl.CountDocuments(ctx, bson.M{"chat_id": chatID})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"chat_id": chatID}}},
+		{{Key: "$project", Value: bson.M{"reaction": bson.M{"$objectToArray": "$reactions"}}}},
+		{{Key: "$unwind", Value: "$reaction"}},
+		{{Key: "$group", Value: bson.M{"_id": "$reaction.k", "total": bson.M{"$sum": "$reaction.v"}}}},
+	}
+
+	cursor, err := reactionsColl.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	totals := make(map[string]int64)
+	for cursor.Next(ctx) {
+		var result struct {
+			Reaction string `bson:"_id"`
+			Total    int64  `bson:"total"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, 0, err
+		}
+		totals[result.Reaction] = result.Total
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return totals, messages, nil
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Schedule is a pending send of a post
+type Schedule struct {
+	ScheduleId string  `bson:"_id" json:"schedule_id"`
+	UserId     int64   `bson:"user_id" json:"user_id"`
+	PostId     string  `bson:"post_id" json:"post_id"`
+	At         int64   `bson:"at" json:"at"`                                 // unix time to send the post at
+	Group      string  `bson:"group,omitempty" json:"group,omitempty"`       // group to send to
+	ChatIds    []int64 `bson:"chat_ids,omitempty" json:"chat_ids,omitempty"` // chats to send to, all connected chats if both are empty
+	Force      bool    `bson:"force,omitempty" json:"force,omitempty"`       // send even if policies that allow it are broken
+}
+
+// AddSchedule stores a pending send of a post
+func AddSchedule(schedule Schedule) error {
+	if _, err := schedulesColl.InsertOne(ctx, schedule); err != nil {
+		log.Printf("[Database] AddSchedule: %v - PostId: %s", err, schedule.PostId)
+		return err
+	}
+	return nil
+}
+
+// DueSchedules retrieves the sends that are due at now
+func DueSchedules(now int64) ([]Schedule, error) {
+	return findSchedules(bson.M{"at": bson.M{"$lte": now}})
+}
+
+// ListSchedules retrieves the pending sends of a post
+func ListSchedules(postID string) ([]Schedule, error) {
+	return findSchedules(bson.M{"post_id": postID})
+}
+
+// ListUserSchedules retrieves the pending sends of a user
+func ListUserSchedules(userID int64) ([]Schedule, error) {
+	return findSchedules(bson.M{"user_id": userID})
+}
+
+// GetSchedule retrieves a pending send by its id, nil if it doesn't exist
+func GetSchedule(scheduleID string) (*Schedule, error) {
+	var schedule Schedule
+	err := findOne(schedulesColl, bson.M{"_id": scheduleID}).Decode(&schedule)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ReplaceSchedule stores a pending send, replacing the one with the same id
+func ReplaceSchedule(schedule Schedule) error {
+	_, err := schedulesColl.ReplaceOne(ctx, bson.M{"_id": schedule.ScheduleId}, schedule, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("[Database] ReplaceSchedule: %v - %s", err, schedule.ScheduleId)
+	}
+	return err
+}
+
+// RemoveSchedule deletes a pending send
+func RemoveSchedule(scheduleID string) error {
+	if err := deleteOne(schedulesColl, bson.M{"_id": scheduleID}); err != nil {
+		log.Printf("[Database] RemoveSchedule: %v - %s", err, scheduleID)
+		return err
+	}
+	return nil
+}
+
+// RemovePostSchedules deletes all pending sends of a post
+func RemovePostSchedules(postID string) error {
+	if _, err := schedulesColl.DeleteMany(ctx, bson.M{"post_id": postID}); err != nil {
+		log.Printf("[Database] RemovePostSchedules: %v - PostId: %s", err, postID)
+		return err
+	}
+	return nil
+}
+
+func findSchedules(filter bson.M) ([]Schedule, error) {
+	cursor, err := find(schedulesColl, filter, options.Find().SetSort(bson.M{"at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var schedules []Schedule
+	if err = cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
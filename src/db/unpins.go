@@ -0,0 +1,47 @@
+package db
+
+import (
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Unpin is a pinned post message that has to be unpinned at a given time
+type Unpin struct {
+	ChatId int64 `bson:"chat_id" json:"chat_id"`
+	MsgId  int64 `bson:"msg_id" json:"msg_id"`
+	At     int64 `bson:"at" json:"at"` // unix time
+}
+
+// AddUnpin schedules a message to be unpinned at the given unix time
+func AddUnpin(chatID, msgID, at int64) error {
+	if err := updateOne(unpinsColl, bson.M{"chat_id": chatID, "msg_id": msgID}, Unpin{ChatId: chatID, MsgId: msgID, At: at}); err != nil {
+		log.Printf("[Database] AddUnpin: %v - Chat: %d, Msg: %d", err, chatID, msgID)
+		return err
+	}
+	return nil
+}
+
+// DueUnpins retrieves the messages that have to be unpinned by the given unix time
+func DueUnpins(now int64) ([]Unpin, error) {
+	cursor, err := find(unpinsColl, bson.M{"at": bson.M{"$lte": now}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var unpins []Unpin
+	if err = cursor.All(ctx, &unpins); err != nil {
+		return nil, err
+	}
+	return unpins, nil
+}
+
+// RemoveUnpin removes the scheduled unpin of a message
+func RemoveUnpin(chatID, msgID int64) error {
+	if err := deleteOne(unpinsColl, bson.M{"chat_id": chatID, "msg_id": msgID}); err != nil {
+		log.Printf("[Database] RemoveUnpin: %v - Chat: %d, Msg: %d", err, chatID, msgID)
+		return err
+	}
+	return nil
+}
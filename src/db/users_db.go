@@ -10,13 +10,18 @@ import (
 )
 
 type UserSettings struct {
-	UserId       int64 `bson:"_id,omitempty" json:"_id,omitempty"`
-	NoNotif      bool  `bson:"nonotif,omitempty" json:"nonotif,omitempty"`
-	Protect      bool  `bson:"protect,omitempty" json:"protect,omitempty"`
-	Spoiler      bool  `bson:"spoiler,omitempty" json:"spoiler,omitempty"`
-	WebPreview   bool  `bson:"webpreview,omitempty" json:"webpreview,omitempty"`
-	CaptionAbove bool  `bson:"captionabove,omitempty" json:"captionabove,omitempty"`
-	ForwardTag   bool  `bson:"forwardtag,omitempty" json:"forwardtag,omitempty"`
+	UserId       int64  `bson:"_id,omitempty" json:"_id,omitempty"`
+	NoNotif      bool   `bson:"nonotif,omitempty" json:"nonotif,omitempty"`
+	Protect      bool   `bson:"protect,omitempty" json:"protect,omitempty"`
+	Spoiler      bool   `bson:"spoiler,omitempty" json:"spoiler,omitempty"`
+	WebPreview   bool   `bson:"webpreview,omitempty" json:"webpreview,omitempty"`
+	CaptionAbove bool   `bson:"captionabove,omitempty" json:"captionabove,omitempty"`
+	ForwardTag   bool   `bson:"forwardtag,omitempty" json:"forwardtag,omitempty"`
+	NoTracking   bool   `bson:"notracking,omitempty" json:"notracking,omitempty"`
+	Timezone     string `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA name used to show times, UTC if empty
+	Language     string `bson:"language,omitempty" json:"language,omitempty"` // language chosen with /lang, Telegram's language_code if empty
+
+	Options *SettingOverrides `bson:"-" json:"-"` // flags of the current delivery, they take precedence over chat overrides
 }
 
 // GetUserSettings retrieves a user's settings or initializes defaults if not found.
@@ -29,6 +34,7 @@ func GetUserSettings(userID int64) *UserSettings {
 		WebPreview:   false,
 		CaptionAbove: false,
 		ForwardTag:   false,
+		NoTracking:   false,
 	}
 	if err := findOne(usersColl, bson.M{"_id": userID}).Decode(settings); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
@@ -70,6 +76,27 @@ func UpdateForwardTag(userID int64, value bool) {
 	updateUserSetting(userID, "forwardtag", value)
 }
 
+// UpdateNoTracking updates the "NoTracking" setting.
+func UpdateNoTracking(userID int64, value bool) {
+	updateUserSetting(userID, "notracking", value)
+}
+
+// UpdateTimezone updates the timezone times are shown in, an empty timezone means UTC.
+func UpdateTimezone(userID int64, timezone string) {
+	update := bson.M{"$set": bson.M{"timezone": timezone}}
+	if _, err := usersColl.UpdateOne(ctx, bson.M{"_id": userID}, update); err != nil {
+		log.Printf("[Database] UpdateTimezone: %v - %d", err, userID)
+	}
+}
+
+// UpdateLanguage updates the language of the bot's messages, an empty language follows the Telegram app.
+func UpdateLanguage(userID int64, language string) {
+	update := bson.M{"$set": bson.M{"language": language}}
+	if _, err := usersColl.UpdateOne(ctx, bson.M{"_id": userID}, update, options.Update().SetUpsert(true)); err != nil {
+		log.Printf("[Database] UpdateLanguage: %v - %d", err, userID)
+	}
+}
+
 // updateUserSetting updates a specific field for a user's settings.
 func updateUserSetting(userID int64, field string, value bool) {
 	update := bson.M{"$set": bson.M{field: value}}
@@ -79,22 +106,35 @@ func updateUserSetting(userID int64, field string, value bool) {
 	}
 }
 
-// ResetUserSettings resets all settings for a user to their default values.
+// ResetUserSettings resets all settings for a user to their default values, the timezone and language are kept.
 func ResetUserSettings(userID int64) {
-	defaultSettings := UserSettings{
-		UserId:       userID,
-		NoNotif:      false,
-		Protect:      false,
-		Spoiler:      false,
-		WebPreview:   false,
-		CaptionAbove: false,
-		ForwardTag:   false,
-	}
-	if err := updateOne(usersColl, bson.M{"_id": userID}, defaultSettings); err != nil {
+	current := GetUserSettings(userID)
+	settings := UserSettings{UserId: userID, Timezone: current.Timezone, Language: current.Language}
+	if err := SetUserSettings(settings); err != nil {
 		log.Printf("[Database] ResetUserSettings: %v - %d", err, userID)
 	}
 }
 
+// SetUserSettings replaces all settings of a user
+func SetUserSettings(settings UserSettings) error {
+	update := bson.M{
+		"nonotif":      settings.NoNotif,
+		"protect":      settings.Protect,
+		"spoiler":      settings.Spoiler,
+		"webpreview":   settings.WebPreview,
+		"captionabove": settings.CaptionAbove,
+		"forwardtag":   settings.ForwardTag,
+		"notracking":   settings.NoTracking,
+		"timezone":     settings.Timezone,
+		"language":     settings.Language,
+	}
+	if err := updateOne(usersColl, bson.M{"_id": settings.UserId}, update); err != nil {
+		log.Printf("[Database] SetUserSettings: %v - %d", err, settings.UserId)
+		return err
+	}
+	return nil
+}
+
 func GetAllUsers() ([]int64, error) {
 	var userIDs []int64
 	projection := options.Find().SetProjection(bson.M{"_id": 1})
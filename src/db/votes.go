@@ -0,0 +1,82 @@
+package db
+
+import (
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Vote represents a user's reaction on a delivered post message
+type Vote struct {
+	PostId string `bson:"post_id,omitempty" json:"post_id,omitempty"`
+	ChatId int64  `bson:"chat_id,omitempty" json:"chat_id,omitempty"`
+	MsgId  int64  `bson:"msg_id,omitempty" json:"msg_id,omitempty"`
+	UserId int64  `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Key    string `bson:"key,omitempty" json:"key,omitempty"`
+}
+
+// ToggleVote sets the user's vote on a post message to key, or removes it if the user already voted for key.
+// It returns true if the vote is set after the toggle. The toggle is a single update, so taps that arrive
+// together are applied one after the other.
+func ToggleVote(postID string, chatID, msgID, userID int64, key string) (bool, error) {
+	filter := bson.M{"chat_id": chatID, "msg_id": msgID, "user_id": userID}
+
+	// Tapping the same button again clears the key, the emptied vote is deleted below
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"post_id": postID,
+		"key":     bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$key", key}}, "", key}},
+	}}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var vote Vote
+	err := votesColl.FindOneAndUpdate(ctx, filter, update, opts).Decode(&vote)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another tap inserted the vote first, apply this one to it
+		err = votesColl.FindOneAndUpdate(ctx, filter, update, opts).Decode(&vote)
+	}
+	if err != nil {
+		log.Printf("[Database] ToggleVote: %v - Chat: %d, Msg: %d, User: %d", err, chatID, msgID, userID)
+		return false, err
+	}
+
+	if vote.Key == "" {
+		// Only an emptied vote is deleted, a tap that set it again in the meantime keeps it
+		_ = deleteOne(votesColl, bson.M{"chat_id": chatID, "msg_id": msgID, "user_id": userID, "key": ""})
+		return false, nil
+	}
+	return true, nil
+}
+
+// GetVoteCounts returns the number of votes per key on a post message
+func GetVoteCounts(chatID, msgID int64) (map[string]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"chat_id": chatID, "msg_id": msgID, "key": bson.M{"$ne": ""}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$key", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := votesColl.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int)
+	for cursor.Next(ctx) {
+		var result struct {
+			Key   string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		counts[result.Key] = result.Count
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"errors"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Webhook is a callback URL that receives the delivery events of a user's posts
+type Webhook struct {
+	WebhookId string   `bson:"_id" json:"webhook_id"`
+	UserId    int64    `bson:"user_id" json:"user_id"`
+	Url       string   `bson:"url" json:"url"`
+	Secret    string   `bson:"secret" json:"-"`                          // key of the HMAC signature of the events
+	Events    []string `bson:"events,omitempty" json:"events,omitempty"` // empty means all events
+}
+
+// AddWebhook stores a new webhook
+func AddWebhook(webhook Webhook) error {
+	if _, err := webhooksColl.InsertOne(ctx, webhook); err != nil {
+		log.Printf("[Database] AddWebhook: %v - %d - %s", err, webhook.UserId, webhook.Url)
+		return err
+	}
+	return nil
+}
+
+// GetWebhook retrieves a webhook by its id, nil if it doesn't exist
+func GetWebhook(webhookID string) (*Webhook, error) {
+	var webhook Webhook
+	err := findOne(webhooksColl, bson.M{"_id": webhookID}).Decode(&webhook)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// RemoveWebhook deletes a webhook
+func RemoveWebhook(webhookID string) error {
+	if err := deleteOne(webhooksColl, bson.M{"_id": webhookID}); err != nil {
+		log.Printf("[Database] RemoveWebhook: %v - %s", err, webhookID)
+		return err
+	}
+	return nil
+}
+
+// ListWebhooks retrieves the webhooks of a user
+func ListWebhooks(userID int64) ([]Webhook, error) {
+	cursor, err := find(webhooksColl, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var webhooks []Webhook
+	if err = cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
@@ -0,0 +1,633 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxApiBody is the largest request body the API reads
+	maxApiBody = 1 << 20
+	// maxScheduleAhead is how far in the future posts can be scheduled
+	maxScheduleAhead = 365 * 24 * time.Hour
+)
+
+// apiPostTypes maps the post types of the API to db post types
+var apiPostTypes = map[string]int{
+	"text":       db.TEXT,
+	"sticker":    db.STICKER,
+	"document":   db.DOCUMENT,
+	"photo":      db.PHOTO,
+	"audio":      db.AUDIO,
+	"voice":      db.VOICE,
+	"video":      db.VIDEO,
+	"video_note": db.VideoNote,
+	"animation":  db.GIF,
+}
+
+func apiPostType(msgType int) string {
+	for name, t := range apiPostTypes {
+		if t == msgType {
+			return name
+		}
+	}
+	return ""
+}
+
+// apiPostRequest is the body of create and edit requests, fields left out keep their value on edits
+type apiPostRequest struct {
+	Type    *string        `json:"type"`
+	Text    *string        `json:"text"` // HTML
+	File    *string        `json:"file"` // Telegram file id, or an http(s) URL for photos
+	Buttons *[]db.Button   `json:"buttons"`
+	Comment string         `json:"comment"` // create only
+	Pin     *db.PinOptions `json:"pin"`     // create only
+	Force   bool           `json:"force"`   // send edits that break content policies which allow it
+}
+
+// apiSendRequest is the body of send and schedule requests
+type apiSendRequest struct {
+	Group   string  `json:"group"`
+	ChatIds []int64 `json:"chat_ids"`
+	At      int64   `json:"at"` // schedule only, unix time
+	In      string  `json:"in"` // schedule only, a duration like 90m or 3d instead of at
+	Force   bool    `json:"force"`
+}
+
+type apiError struct {
+	Error  string `json:"error"`
+	Policy string `json:"policy,omitempty"`
+}
+
+type apiDelivery struct {
+	ChatId    int64  `json:"chat_id"`
+	MessageId int64  `json:"message_id,omitempty"`
+	Link      string `json:"link,omitempty"`
+	Comment   bool   `json:"comment,omitempty"`
+	Error     string `json:"error,omitempty"`
+	PinError  string `json:"pin_error,omitempty"`
+}
+
+type apiPost struct {
+	PostId     string         `json:"post_id"`
+	Type       string         `json:"type"`
+	Text       string         `json:"text,omitempty"`
+	File       string         `json:"file,omitempty"`
+	Buttons    []db.Button    `json:"buttons,omitempty"`
+	Comment    string         `json:"comment,omitempty"`
+	Pin        *db.PinOptions `json:"pin,omitempty"`
+	Versions   int            `json:"versions"`
+	Deliveries []apiDelivery  `json:"deliveries"`
+	Schedules  []db.Schedule  `json:"schedules"`
+}
+
+// apiHandlerFunc handles an authenticated API request and returns the status and body of the response
+type apiHandlerFunc func(b *gotgbot.Bot, r *http.Request, userId int64) (int, any)
+
+// registerApiRoutes adds the REST API for publishing posts
+func registerApiRoutes(mux *http.ServeMux, b *gotgbot.Bot) {
+	mux.HandleFunc("POST /api/v1/posts", apiHandler(b, apiCreatePost))
+	mux.HandleFunc("GET /api/v1/posts/{id}", apiHandler(b, apiGetPost))
+	mux.HandleFunc("PATCH /api/v1/posts/{id}", apiHandler(b, apiEditPost))
+	mux.HandleFunc("DELETE /api/v1/posts/{id}", apiHandler(b, apiDeletePost))
+	mux.HandleFunc("POST /api/v1/posts/{id}/send", apiHandler(b, apiSendPost))
+	mux.HandleFunc("POST /api/v1/posts/{id}/schedules", apiHandler(b, apiSchedulePost))
+	mux.HandleFunc("DELETE /api/v1/posts/{id}/schedules", apiHandler(b, apiCancelSchedules))
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiHandler authenticates a request by its bearer API key and writes the handler's result as JSON
+func apiHandler(b *gotgbot.Bot, handler apiHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, body := http.StatusUnauthorized, any(apiError{Error: "missing or invalid API key"})
+
+		if key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			apiKey, err := db.GetApiKey(hashApiKey(strings.TrimSpace(key)))
+			switch {
+			case err != nil:
+				log.Printf("[api] GetApiKey: %v", err)
+				status, body = http.StatusInternalServerError, apiError{Error: "internal error"}
+			case apiKey != nil && !db.IsUserBanned(apiKey.UserId):
+				r.Body = http.MaxBytesReader(w, r.Body, maxApiBody)
+				status, body = handler(b, r, apiKey.UserId)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func decodeApiBody(r *http.Request, v any) *apiError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return &apiError{Error: "invalid JSON body: " + err.Error()}
+	}
+	return nil
+}
+
+// apiUserPost retrieves the post of the request path, if it belongs to the user
+func apiUserPost(r *http.Request, userId int64) (*db.Post, int, any) {
+	post, err := db.GetPost(r.PathValue("id"))
+	if err != nil {
+		log.Printf("[api] GetPost: %v", err)
+		return nil, http.StatusInternalServerError, apiError{Error: "internal error"}
+	}
+	if post == nil || post.UserId != userId {
+		return nil, http.StatusNotFound, apiError{Error: "post not found"}
+	}
+	return post, 0, nil
+}
+
+// apiTargets returns the chats a send goes to: the given chats, the chats of a group or all connected chats
+func apiTargets(userId int64, group string, chatIds []int64) ([]int64, error) {
+	if len(chatIds) == 0 {
+		chats, err := groupChats(userId, group)
+		if err == nil && len(chats) == 0 {
+			err = errors.New("no connected chats to send to")
+		}
+		return chats, err
+	}
+
+	connected := db.Connection(userId).ChatIds
+	for _, chatId := range chatIds {
+		if !helpers.Contains(connected, chatId) {
+			return nil, fmt.Errorf("chat %d is not one of your connected chats", chatId)
+		}
+	}
+	return chatIds, nil
+}
+
+// apiRightsCheck returns the error response for target chats where the user or the bot lacks one of the rights
+func apiRightsCheck(b *gotgbot.Bot, userId int64, chatIds []int64, rights ...onlyAdmins.Right) *apiError {
+	_, refused := permittedChats(b, userId, chatIds, rights...)
+	if len(refused) == 0 {
+		return nil
+	}
+
+	reasons := make([]string, 0, len(refused))
+	for _, refusal := range refused {
+		reasons = append(reasons, fmt.Sprintf("chat %d: %s", refusal.ChatId, i18n.T(i18n.Default, refusal.Reason)))
+	}
+	return &apiError{Error: "missing admin rights in " + strings.Join(reasons, "; ")}
+}
+
+// apiPolicyCheck returns the error response for posts breaking the policies of the target chats
+func apiPolicyCheck(userId int64, chatIds []int64, text string, buttons []db.Button, force bool) *apiError {
+	report, confirm := policyReport(i18n.Default, userId, chatIds, text, buttons)
+	if report == "" || (force && confirm) {
+		return nil
+	}
+
+	message := "the post breaks your content policies"
+	if confirm {
+		message += ", set force to send it anyway"
+	}
+	return &apiError{Error: message, Policy: strings.TrimSpace(helpers.PlainText(report))}
+}
+
+// applyPostRequest applies the fields of a request to a post's content and validates the result
+func applyPostRequest(post *db.Post, req *apiPostRequest) string {
+	if req.Type != nil {
+		msgType, ok := apiPostTypes[*req.Type]
+		if !ok {
+			return fmt.Sprintf("unknown post type %q", *req.Type)
+		}
+		post.MsgType = msgType
+	}
+	if req.Text != nil {
+		post.FilterReply = strings.TrimSpace(*req.Text)
+	}
+	if req.File != nil {
+		post.FileID = strings.TrimSpace(*req.File)
+	}
+	if req.Buttons != nil {
+		buttons, errorMsg := helpers.CheckPostButtons(*req.Buttons)
+		if errorMsg != "" {
+			return helpers.PlainText(errorMsg)
+		}
+		post.Buttons = buttons
+	}
+
+	length := helpers.TextLength(helpers.PlainText(post.FilterReply))
+	switch {
+	case post.MsgType == 0:
+		return "type is required"
+	case post.MsgType == db.TEXT && post.FilterReply == "":
+		return "text posts need a text"
+	case post.MsgType == db.TEXT && length > maxTextLength:
+		return fmt.Sprintf("text is %d characters long, the limit is %d", length, maxTextLength)
+	case post.MsgType != db.TEXT && post.FileID == "":
+		return "media posts need a file"
+	case post.MsgType != db.TEXT && length > maxCaptionLength:
+		return fmt.Sprintf("caption is %d characters long, the limit is %d", length, maxCaptionLength)
+	case helpers.IsWebURL(post.FileID) && post.MsgType != db.PHOTO:
+		return "only photos can be given as URL"
+	}
+
+	if post.MsgType == db.TEXT {
+		post.FileID = ""
+	}
+	return ""
+}
+
+func apiPostView(b *gotgbot.Bot, post *db.Post) apiPost {
+	view := apiPost{
+		PostId:     post.PostId,
+		Type:       apiPostType(post.MsgType),
+		Text:       post.FilterReply,
+		File:       post.FileID,
+		Buttons:    post.Buttons,
+		Comment:    post.Comment,
+		Pin:        post.Pin,
+		Versions:   len(post.Versions),
+		Deliveries: []apiDelivery{},
+		Schedules:  []db.Schedule{},
+	}
+
+	for _, chat := range post.Chats {
+		// Previews from !create live in the chat with the bot
+		if chat.ChatId == b.Id {
+			continue
+		}
+		view.Deliveries = append(view.Deliveries, apiDelivery{
+			ChatId:    chat.ChatId,
+			MessageId: chat.MsgId,
+			Link:      helpers.GetMessageLink(chat.ChatId, chat.MsgId),
+			Comment:   chat.Comment,
+		})
+	}
+
+	if schedules, err := db.ListSchedules(post.PostId); err == nil && schedules != nil {
+		view.Schedules = schedules
+	}
+	return view
+}
+
+func apiCreatePost(b *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	var req apiPostRequest
+	if errResp := decodeApiBody(r, &req); errResp != nil {
+		return http.StatusBadRequest, errResp
+	}
+
+	post := &db.Post{PostId: helpers.GenerateUniqueString(), UserId: userId}
+	if errorMsg := applyPostRequest(post, &req); errorMsg != "" {
+		return http.StatusUnprocessableEntity, apiError{Error: errorMsg}
+	}
+
+	if err := db.SetPostContent(post.PostId, userId, post.MsgType, post.FileID, post.Buttons, post.FilterReply); err != nil {
+		return http.StatusInternalServerError, apiError{Error: "error saving the post"}
+	}
+	if req.Comment = strings.TrimSpace(req.Comment); req.Comment != "" {
+		post.Comment = req.Comment
+		_ = db.SetPostComment(post.PostId, post.Comment)
+	}
+	if req.Pin != nil {
+		post.Pin = req.Pin
+		_ = db.SetPostPin(post.PostId, post.Pin)
+	}
+
+	return http.StatusCreated, apiPostView(b, post)
+}
+
+func apiGetPost(b *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	post, status, errResp := apiUserPost(r, userId)
+	if post == nil {
+		return status, errResp
+	}
+	return http.StatusOK, apiPostView(b, post)
+}
+
+func apiSendPost(b *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	post, status, errResp := apiUserPost(r, userId)
+	if post == nil {
+		return status, errResp
+	}
+
+	var req apiSendRequest
+	if errResp := decodeApiBody(r, &req); errResp != nil {
+		return http.StatusBadRequest, errResp
+	}
+
+	chatIds, err := apiTargets(userId, req.Group, req.ChatIds)
+	if err != nil {
+		return http.StatusUnprocessableEntity, apiError{Error: err.Error()}
+	}
+	if errResp := apiRightsCheck(b, userId, chatIds, onlyAdmins.CanPostMessages); errResp != nil {
+		return http.StatusForbidden, errResp
+	}
+	if errResp := apiPolicyCheck(userId, chatIds, post.FilterReply, post.Buttons, req.Force); errResp != nil {
+		return http.StatusUnprocessableEntity, errResp
+	}
+
+	results := make([]apiDelivery, 0, len(chatIds))
+	for _, delivery := range deliverPost(b, nil, userId, post.PostId, post, chatIds) {
+		result := apiDelivery{ChatId: delivery.ChatId}
+		if delivery.Err != nil {
+			result.Error = delivery.Err.Error()
+		} else {
+			result.MessageId = delivery.Message.MessageId
+			result.Link = delivery.Message.GetLink()
+		}
+		if delivery.PinErr != nil {
+			result.PinError = delivery.PinErr.Error()
+		}
+		results = append(results, result)
+	}
+
+	return http.StatusOK, map[string]any{"post_id": post.PostId, "deliveries": results}
+}
+
+func apiEditPost(b *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	post, status, errResp := apiUserPost(r, userId)
+	if post == nil {
+		return status, errResp
+	}
+
+	var req apiPostRequest
+	if errResp := decodeApiBody(r, &req); errResp != nil {
+		return http.StatusBadRequest, errResp
+	}
+
+	edited := *post
+	if errorMsg := applyPostRequest(&edited, &req); errorMsg != "" {
+		return http.StatusUnprocessableEntity, apiError{Error: errorMsg}
+	}
+
+	var postChats []int64
+	for _, chat := range post.Chats {
+		if !chat.Comment && chat.ChatId != b.Id {
+			postChats = append(postChats, chat.ChatId)
+		}
+	}
+	if errResp := apiRightsCheck(b, userId, postChats, onlyAdmins.CanEditMessages); errResp != nil {
+		return http.StatusForbidden, errResp
+	}
+	if errResp := apiPolicyCheck(userId, postChats, edited.FilterReply, edited.Buttons, req.Force); errResp != nil {
+		return http.StatusUnprocessableEntity, errResp
+	}
+
+	userSetting := db.GetUserSettings(userId)
+	userSetting.Options = post.Options
+	marks := make(watermarkCache)
+	results := make([]apiDelivery, 0, len(postChats))
+	done := 0
+	for _, chat := range post.Chats {
+		if chat.Comment || chat.ChatId == b.Id {
+			continue
+		}
+
+		result := apiDelivery{ChatId: chat.ChatId, MessageId: chat.MsgId}
+		chatSetting := db.GetChatSettings(userId, chat.ChatId)
+		err := editPostMessage(b, chatSetting, chat.MsgId, post.MsgType, post.PostId, edited.MsgType, edited.FilterReply, edited.FileID, edited.Buttons, userSetting, marks)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			_, _ = db.AddPost(post.PostId, userId, chat.ChatId, chat.MsgId, edited.MsgType, edited.FileID, edited.Buttons, edited.FilterReply)
+			done++
+		}
+		results = append(results, result)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// Drafts have no messages, their content is replaced directly
+	if len(postChats) == 0 {
+		if err := db.SetPostContent(post.PostId, userId, edited.MsgType, edited.FileID, edited.Buttons, edited.FilterReply); err != nil {
+			return http.StatusInternalServerError, apiError{Error: "error saving the post"}
+		}
+	} else if done == 0 {
+		return http.StatusBadGateway, map[string]any{"error": "the post could not be edited in any chat", "deliveries": results}
+	}
+	_ = db.AddPostVersions(post.PostId, post.Version(time.Now().Unix()))
+
+	view := apiPostView(b, &edited)
+	view.Versions++
+	view.Deliveries = results
+	return http.StatusOK, view
+}
+
+func apiDeletePost(b *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	post, status, errResp := apiUserPost(r, userId)
+	if post == nil {
+		return status, errResp
+	}
+
+	var postChats []int64
+	for _, chat := range post.Chats {
+		if chat.ChatId != b.Id && !helpers.Contains(postChats, chat.ChatId) {
+			postChats = append(postChats, chat.ChatId)
+		}
+	}
+	if errResp := apiRightsCheck(b, userId, postChats, onlyAdmins.CanDeleteMessages); errResp != nil {
+		return http.StatusForbidden, errResp
+	}
+
+	results := make([]apiDelivery, 0, len(post.Chats))
+	for _, chat := range post.Chats {
+		if chat.ChatId == b.Id {
+			continue
+		}
+
+		result := apiDelivery{ChatId: chat.ChatId, MessageId: chat.MsgId, Comment: chat.Comment}
+		if _, err := b.DeleteMessage(chat.ChatId, chat.MsgId, nil); err != nil {
+			result.Error = err.Error()
+		} else {
+			emitDeleted(userId, post.PostId, chat.ChatId, chat.MsgId)
+		}
+		results = append(results, result)
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	_ = db.RemovePostSchedules(post.PostId)
+	_ = db.RemovePost(post.PostId)
+	return http.StatusOK, map[string]any{"post_id": post.PostId, "deliveries": results}
+}
+
+func apiSchedulePost(b *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	post, status, errResp := apiUserPost(r, userId)
+	if post == nil {
+		return status, errResp
+	}
+
+	var req apiSendRequest
+	if errResp := decodeApiBody(r, &req); errResp != nil {
+		return http.StatusBadRequest, errResp
+	}
+
+	at := req.At
+	if req.In != "" {
+		duration, err := helpers.ParseDuration(req.In)
+		if err != nil {
+			return http.StatusUnprocessableEntity, apiError{Error: err.Error()}
+		}
+		at = time.Now().Add(duration).Unix()
+	}
+	if at <= time.Now().Unix() || at > time.Now().Add(maxScheduleAhead).Unix() {
+		return http.StatusUnprocessableEntity, apiError{Error: "set at or in to a time in the next 365 days"}
+	}
+
+	chatIds, err := apiTargets(userId, req.Group, req.ChatIds)
+	if err != nil {
+		return http.StatusUnprocessableEntity, apiError{Error: err.Error()}
+	}
+	if errResp := apiRightsCheck(b, userId, chatIds, onlyAdmins.CanPostMessages); errResp != nil {
+		return http.StatusForbidden, errResp
+	}
+	if errResp := apiPolicyCheck(userId, chatIds, post.FilterReply, post.Buttons, req.Force); errResp != nil {
+		return http.StatusUnprocessableEntity, errResp
+	}
+
+	schedule := db.Schedule{
+		ScheduleId: helpers.GenerateUniqueString(),
+		UserId:     userId,
+		PostId:     post.PostId,
+		At:         at,
+		Group:      req.Group,
+		ChatIds:    req.ChatIds,
+		Force:      req.Force,
+	}
+	if err = db.AddSchedule(schedule); err != nil {
+		return http.StatusInternalServerError, apiError{Error: "error saving the schedule"}
+	}
+	return http.StatusCreated, schedule
+}
+
+func apiCancelSchedules(_ *gotgbot.Bot, r *http.Request, userId int64) (int, any) {
+	post, status, errResp := apiUserPost(r, userId)
+	if post == nil {
+		return status, errResp
+	}
+
+	if err := db.RemovePostSchedules(post.PostId); err != nil {
+		return http.StatusInternalServerError, apiError{Error: "error removing the schedules"}
+	}
+	return http.StatusOK, map[string]any{"post_id": post.PostId, "schedules": []db.Schedule{}}
+}
+
+// runSchedules sends the scheduled posts that are due
+func runSchedules(b *gotgbot.Bot) {
+	schedules, err := db.DueSchedules(time.Now().Unix())
+	if err != nil {
+		log.Printf("[api] DueSchedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		// Remove the schedule first, a failing send is reported instead of retried every minute
+		if err = db.RemoveSchedule(schedule.ScheduleId); err != nil {
+			continue
+		}
+
+		post, err := db.GetPost(schedule.PostId)
+		if err != nil || post == nil {
+			log.Printf("[api] Scheduled post %s not found: %v", schedule.PostId, err)
+			continue
+		}
+
+		lang := i18n.UserLang(schedule.UserId)
+		chatIds, err := apiTargets(schedule.UserId, schedule.Group, schedule.ChatIds)
+		var refused []chatRefusal
+		if err == nil {
+			chatIds, refused = permittedChats(b, schedule.UserId, chatIds, onlyAdmins.CanPostMessages)
+		}
+		if err != nil || len(chatIds) == 0 {
+			reason := strings.Join(refusalLines(lang, refused), ", ")
+			if err != nil {
+				reason = html.EscapeString(err.Error())
+			}
+			text := trUser(schedule.UserId, "⏰ The scheduled post <code>%s</code> was not sent: %s", post.PostId, reason)
+			_, _ = b.SendMessage(schedule.UserId, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML})
+			continue
+		}
+
+		// The policies may have changed since the post was scheduled, and a group may have new chats
+		intro := trUser(schedule.UserId, "⏰ The scheduled post <code>%s</code> was not sent, it breaks your content policies:\n\n", post.PostId)
+		if policyBlocked(b, schedule.UserId, chatIds, post.FilterReply, post.Buttons, schedule.Force, intro) {
+			continue
+		}
+
+		var failedChats, pinFailed []string
+		sent := 0
+		for _, delivery := range deliverPost(b, nil, schedule.UserId, post.PostId, post, chatIds) {
+			if delivery.Err != nil {
+				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", delivery.ChatId))
+				continue
+			}
+			if delivery.PinErr != nil {
+				pinFailed = append(pinFailed, pinFailure(lang, delivery.ChatId, delivery.PinErr))
+			}
+			sent++
+		}
+
+		text := i18n.T(lang, "⏰ Sent a scheduled post to %d chats.\n", sent)
+		if len(failedChats) > 0 {
+			text += i18n.T(lang, "❌ Failed to send to: %s\n", strings.Join(failedChats, ", "))
+		}
+		if len(refused) > 0 {
+			text += i18n.T(lang, "🚫 Skipped, admin rights are missing: %s\n", strings.Join(refusalLines(lang, refused), ", "))
+		}
+		text += "\n" + pinSummary(lang, pinFailed)
+		text += i18n.T(lang, "<b>PostId:</b> <code>%s</code>", post.PostId)
+		_, _ = b.SendMessage(schedule.UserId, text, &gotgbot.SendMessageOpts{
+			ParseMode:           gotgbot.ParseModeHTML,
+			ReplyMarkup:         helpers.PostButton(lang, post.PostId),
+			DisableNotification: true,
+		})
+	}
+}
+
+func apiKey(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) > 0 && (args[0] == "revoke" || args[0] == "off") {
+		if err := db.RemoveApiKeys(msg.From.Id); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error revoking the API key."), helpers.Shtml())
+			return err
+		}
+		_, err := msg.Reply(b, tr(ctx, "Your API key was revoked."), helpers.Shtml())
+		return err
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	key := "cm_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := db.SetApiKey(db.ApiKey{Hash: hashApiKey(key), UserId: msg.From.Id, Created: time.Now().Unix()}); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the API key."), helpers.Shtml())
+		return err
+	}
+
+	text := tr(ctx, "🔑 <b>Your API key:</b>\n<code>%s</code>\n\n"+
+		"Send it as <code>Authorization: Bearer key</code> to the <code>/api/v1</code> endpoints of the bot. "+
+		"It is shown only once and replaces your previous key.\n"+
+		"Use <code>/apikey revoke</code> to revoke it.", key)
+	_, err := msg.Reply(b, text, helpers.Shtml())
+	return err
+}
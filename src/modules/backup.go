@@ -0,0 +1,480 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupVersion = 1
+	// maxBackupSize is the largest backup file /import accepts
+	maxBackupSize = 5 << 20
+	// maxBackupChats is the number of distinct chats a backup may reference, each one is checked on import
+	maxBackupChats = 300
+	// importTimeout is how long the buttons of an import report work
+	importTimeout = 10 * time.Minute
+)
+
+// userBackup is the JSON document written by /export and read by /import
+type userBackup struct {
+	Version     int             `json:"version"`
+	UserId      int64           `json:"user_id"`
+	Exported    int64           `json:"exported"` // unix time
+	Connections []int64         `json:"connections"`
+	Settings    db.UserSettings `json:"settings"`
+	Posts       []db.Post       `json:"posts"`
+	Groups      []db.ChatGroup  `json:"groups"`
+	Schedules   []db.Schedule   `json:"schedules"`
+}
+
+// importPlan is a checked backup waiting for the user to pick how conflicts are handled
+type importPlan struct {
+	UserId    int64
+	Backup    userBackup
+	Conflicts map[string]bool // "settings", "post:id", "group:name" and "schedule:id" entries that already exist and differ
+}
+
+var importPlans = struct {
+	sync.Mutex
+	plans map[string]importPlan
+}{plans: make(map[string]importPlan)}
+
+func exportData(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	userId := msg.From.Id
+	backup := userBackup{
+		Version:     backupVersion,
+		UserId:      userId,
+		Exported:    time.Now().Unix(),
+		Connections: db.Connection(userId).ChatIds,
+		Settings:    *db.GetUserSettings(userId),
+	}
+
+	var err error
+	if backup.Posts, err = db.ListPosts(userId); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error reading your posts."), helpers.Shtml())
+		return err
+	}
+	if backup.Groups, err = db.ListChatGroups(userId); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error reading your groups."), helpers.Shtml())
+		return err
+	}
+	if backup.Schedules, err = db.ListUserSchedules(userId); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error reading your schedules."), helpers.Shtml())
+		return err
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("channelManager-%d-%s.json", userId, time.Now().Format("2006-01-02"))
+	caption := tr(ctx, "Backup of %d connections, %d posts, %d groups and %d schedules.\n\nReply to this file with <code>/import</code> to restore it.",
+		len(backup.Connections), len(backup.Posts), len(backup.Groups), len(backup.Schedules))
+	_, err = b.SendDocument(msg.Chat.Id, gotgbot.InputFileByReader(name, bytes.NewReader(data)), &gotgbot.SendDocumentOpts{
+		Caption:         caption,
+		ParseMode:       gotgbot.ParseModeHTML,
+		ReplyParameters: &gotgbot.ReplyParameters{MessageId: msg.MessageId, AllowSendingWithoutReply: true},
+	})
+	return err
+}
+
+// validateBackup checks the structure of a backup and returns the first problem found
+func validateBackup(lang string, backup *userBackup) string {
+	if backup.Version != backupVersion {
+		return i18n.T(lang, "Unsupported backup version %d.", backup.Version)
+	}
+
+	postIds := make(map[string]bool)
+	for i := range backup.Posts {
+		post := &backup.Posts[i]
+		if post.PostId == "" || len(post.PostId) > 64 || strings.ContainsAny(post.PostId, " \n") {
+			return i18n.T(lang, "Post %d has an invalid PostId.", i+1)
+		}
+		if postIds[post.PostId] {
+			return i18n.T(lang, "Post <code>%s</code> appears twice.", html.EscapeString(post.PostId))
+		}
+		postIds[post.PostId] = true
+
+		msgType := apiPostType(post.MsgType)
+		req := apiPostRequest{Type: &msgType, Text: &post.FilterReply, File: &post.FileID}
+		if len(post.Buttons) > 0 {
+			req.Buttons = &post.Buttons
+		}
+		if errorMsg := applyPostRequest(post, &req); errorMsg != "" {
+			return i18n.T(lang, "Post <code>%s</code>: %s.", html.EscapeString(post.PostId), html.EscapeString(errorMsg))
+		}
+	}
+
+	if backup.Settings.Language != "" && !i18n.Supported(backup.Settings.Language) {
+		backup.Settings.Language = ""
+	}
+	if !helpers.ValidTimezone(backup.Settings.Timezone) {
+		return i18n.T(lang, "Unknown timezone <code>%s</code>.", html.EscapeString(backup.Settings.Timezone))
+	}
+
+	groups := make(map[string]bool)
+	for _, group := range backup.Groups {
+		if !groupNameRegex.MatchString(group.Name) {
+			return i18n.T(lang, "The group name <code>%s</code> is invalid.", html.EscapeString(group.Name))
+		}
+		if groups[group.Name] {
+			return i18n.T(lang, "Group <code>%s</code> appears twice.", group.Name)
+		}
+		groups[group.Name] = true
+	}
+
+	scheduleIds := make(map[string]bool)
+	for i, schedule := range backup.Schedules {
+		if schedule.ScheduleId == "" || len(schedule.ScheduleId) > 64 || scheduleIds[schedule.ScheduleId] {
+			return i18n.T(lang, "Schedule %d has an invalid or repeated id.", i+1)
+		}
+		scheduleIds[schedule.ScheduleId] = true
+		if schedule.PostId == "" || schedule.At <= 0 {
+			return i18n.T(lang, "Schedule <code>%s</code> has no post or time.", html.EscapeString(schedule.ScheduleId))
+		}
+	}
+	return ""
+}
+
+// backupChats returns the distinct chats referenced by a backup, leaving out the previews of !create
+func backupChats(b *gotgbot.Bot, backup *userBackup) []int64 {
+	var chatIds []int64
+	add := func(chatId int64) {
+		if chatId != 0 && chatId != b.Id && !slices.Contains(chatIds, chatId) {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+
+	for _, chatId := range backup.Connections {
+		add(chatId)
+	}
+	for _, post := range backup.Posts {
+		for _, chat := range post.Chats {
+			add(chat.ChatId)
+		}
+		if post.Source != nil {
+			add(post.Source.ChatId)
+		}
+	}
+	for _, group := range backup.Groups {
+		for _, chatId := range group.ChatIds {
+			add(chatId)
+		}
+	}
+	for _, schedule := range backup.Schedules {
+		for _, chatId := range schedule.ChatIds {
+			add(chatId)
+		}
+	}
+	return chatIds
+}
+
+// filterBackup removes the chats the user may not manage from a backup and makes the user the owner of everything in it.
+// It returns notes about the skipped posts and schedules.
+func filterBackup(b *gotgbot.Bot, lang string, backup *userBackup, userId int64, allowed map[int64]bool) []string {
+	var notes []string
+	keep := func(chatIds []int64) []int64 {
+		var kept []int64
+		for _, chatId := range chatIds {
+			if allowed[chatId] {
+				kept = append(kept, chatId)
+			}
+		}
+		return kept
+	}
+
+	// Previews live in the chat with the bot and belong to the user who created them
+	ownPreviews := backup.UserId == userId
+
+	backup.Connections = keep(backup.Connections)
+	backup.Settings.UserId = userId
+
+	var posts []db.Post
+	for _, post := range backup.Posts {
+		existing, _ := db.GetPost(post.PostId)
+		if existing != nil && existing.UserId != userId {
+			notes = append(notes, i18n.T(lang, "Post <code>%s</code> belongs to another user, skipped.", html.EscapeString(post.PostId)))
+			continue
+		}
+
+		var chats []db.Chat
+		for _, chat := range post.Chats {
+			if allowed[chat.ChatId] || (chat.ChatId == b.Id && ownPreviews) {
+				chats = append(chats, chat)
+			}
+		}
+		post.Chats = chats
+		if post.Source != nil && !allowed[post.Source.ChatId] {
+			post.Source = nil
+		}
+		post.UserId = userId
+		posts = append(posts, post)
+	}
+	backup.Posts = posts
+
+	for i := range backup.Groups {
+		backup.Groups[i].UserId = userId
+		backup.Groups[i].ChatIds = keep(backup.Groups[i].ChatIds)
+	}
+
+	now := time.Now().Unix()
+	var schedules []db.Schedule
+	for _, schedule := range backup.Schedules {
+		inBackup := slices.ContainsFunc(backup.Posts, func(post db.Post) bool { return post.PostId == schedule.PostId })
+		existing, _ := db.GetSchedule(schedule.ScheduleId)
+		switch {
+		case schedule.At <= now:
+			notes = append(notes, i18n.T(lang, "Schedule <code>%s</code> is in the past, skipped.", html.EscapeString(schedule.ScheduleId)))
+			continue
+		case existing != nil && existing.UserId != userId:
+			notes = append(notes, i18n.T(lang, "Schedule <code>%s</code> belongs to another user, skipped.", html.EscapeString(schedule.ScheduleId)))
+			continue
+		case !inBackup:
+			if post, _ := db.GetPost(schedule.PostId); post == nil || post.UserId != userId {
+				notes = append(notes, i18n.T(lang, "Schedule <code>%s</code> is for an unknown post, skipped.", html.EscapeString(schedule.ScheduleId)))
+				continue
+			}
+		}
+
+		if len(schedule.ChatIds) > 0 {
+			if schedule.ChatIds = keep(schedule.ChatIds); len(schedule.ChatIds) == 0 {
+				notes = append(notes, i18n.T(lang, "Schedule <code>%s</code> has no chats left, skipped.", html.EscapeString(schedule.ScheduleId)))
+				continue
+			}
+		}
+		schedule.UserId = userId
+		schedules = append(schedules, schedule)
+	}
+	backup.Schedules = schedules
+	return notes
+}
+
+// backupConflicts finds the entries of a backup that would replace different existing data of the user
+func backupConflicts(backup *userBackup, userId int64) map[string]bool {
+	conflicts := make(map[string]bool)
+	if current := db.GetUserSettings(userId); *current != backup.Settings {
+		conflicts["settings"] = true
+	}
+
+	for _, post := range backup.Posts {
+		if existing, _ := db.GetPost(post.PostId); existing != nil && !sameBackupEntry(*existing, post) {
+			conflicts["post:"+post.PostId] = true
+		}
+	}
+	for _, group := range backup.Groups {
+		existing, _ := db.GetChatGroup(userId, group.Name)
+		if existing != nil && !slices.Equal(existing.ChatIds, group.ChatIds) {
+			conflicts["group:"+group.Name] = true
+		}
+	}
+	for _, schedule := range backup.Schedules {
+		if existing, _ := db.GetSchedule(schedule.ScheduleId); existing != nil && !sameBackupEntry(*existing, schedule) {
+			conflicts["schedule:"+schedule.ScheduleId] = true
+		}
+	}
+	return conflicts
+}
+
+// sameBackupEntry reports whether a stored entry and its backup hold the same data, as they would be exported
+func sameBackupEntry(existing, entry any) bool {
+	a, errA := json.Marshal(existing)
+	b, errB := json.Marshal(entry)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+func importData(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	reply := msg.ReplyToMessage
+	if reply == nil || reply.Document == nil {
+		_, err := msg.Reply(b, tr(ctx, "Reply to a backup file from <code>/export</code> with <code>/import</code>."), helpers.Shtml())
+		return err
+	}
+	if reply.Document.FileSize > maxBackupSize {
+		_, err := msg.Reply(b, tr(ctx, "This file is too large to be a backup."), helpers.Shtml())
+		return err
+	}
+
+	data, err := helpers.DownloadFile(b, reply.Document.FileId)
+	if err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Failed to download the file."), helpers.Shtml())
+		return err
+	}
+
+	var backup userBackup
+	if err = json.Unmarshal(data, &backup); err != nil {
+		_, err = msg.Reply(b, tr(ctx, "This is not a valid backup: %s", html.EscapeString(err.Error())), helpers.Shtml())
+		return err
+	}
+	lang := i18n.Lang(ctx.EffectiveUser)
+	if errorMsg := validateBackup(lang, &backup); errorMsg != "" {
+		_, err = msg.Reply(b, tr(ctx, "This is not a valid backup: %s", errorMsg), helpers.Shtml())
+		return err
+	}
+
+	chatIds := backupChats(b, &backup)
+	if len(chatIds) > maxBackupChats {
+		_, err = msg.Reply(b, tr(ctx, "The backup references %d chats, up to %d can be imported.", len(chatIds), maxBackupChats), helpers.Shtml())
+		return err
+	}
+
+	status, _ := msg.Reply(b, tr(ctx, "Checking your admin rights in %d chats...", len(chatIds)), helpers.Shtml())
+
+	var report strings.Builder
+	allowed := make(map[int64]bool)
+	for _, chatId := range chatIds {
+		if _, failure := checkChatAdmin(b, chatId, msg.From.Id); failure != "" {
+			report.WriteString(fmt.Sprintf("<code>%d</code> (%s)\n", chatId, tr(ctx, failure)))
+			continue
+		}
+		allowed[chatId] = true
+	}
+
+	text := tr(ctx, "<b>Backup from %s</b>\n\n", helpers.FormatTime(time.Unix(backup.Exported, 0), db.GetUserSettings(msg.From.Id).Timezone))
+	if report.Len() > 0 {
+		text += tr(ctx, "❌ <b>These chats are left out:</b>\n") + report.String() + "\n"
+	}
+
+	notes := filterBackup(b, lang, &backup, msg.From.Id, allowed)
+	conflicts := backupConflicts(&backup, msg.From.Id)
+
+	text += tr(ctx, "The backup has %d connections, %d posts, %d groups and %d schedules to import.\n\n",
+		len(backup.Connections), len(backup.Posts), len(backup.Groups), len(backup.Schedules))
+	if len(notes) > 0 {
+		text += tr(ctx, "<b>Notes:</b>\n") + strings.Join(shortList(lang, notes), "\n") + "\n\n"
+	}
+	if len(conflicts) > 0 {
+		keys := make([]string, 0, len(conflicts))
+		for key := range conflicts {
+			keys = append(keys, "<code>"+html.EscapeString(key)+"</code>")
+		}
+		slices.Sort(keys)
+		text += tr(ctx, "⚠️ <b>%d conflicts with your current data:</b>\n%s\n\n", len(conflicts), strings.Join(shortList(lang, keys), ", "))
+	}
+	text += tr(ctx, "Nothing was changed yet. Choose how to import the backup.")
+
+	key := fmt.Sprintf("%d:%d", msg.Chat.Id, msg.MessageId)
+	importPlans.Lock()
+	importPlans.plans[key] = importPlan{UserId: msg.From.Id, Backup: backup, Conflicts: conflicts}
+	importPlans.Unlock()
+	time.AfterFunc(importTimeout, func() {
+		importPlans.Lock()
+		delete(importPlans.plans, key)
+		importPlans.Unlock()
+	})
+
+	buttons := [][]gotgbot.InlineKeyboardButton{{{Text: tr(ctx, "📥 Import"), CallbackData: "import.skip." + key}}}
+	if len(conflicts) > 0 {
+		buttons = [][]gotgbot.InlineKeyboardButton{
+			{{Text: tr(ctx, "📥 Import (skip conflicts)"), CallbackData: "import.skip." + key}},
+			{{Text: tr(ctx, "⚠️ Import (overwrite)"), CallbackData: "import.overwrite." + key}},
+		}
+	}
+	buttons = append(buttons, []gotgbot.InlineKeyboardButton{{Text: tr(ctx, "Cancel"), CallbackData: "import.cancel." + key}})
+
+	if status != nil {
+		_, _ = status.Delete(b, nil)
+	}
+	opts := helpers.Shtml()
+	opts.ReplyMarkup = gotgbot.InlineKeyboardMarkup{InlineKeyboard: buttons}
+	_, err = msg.Reply(b, text, opts)
+	return err
+}
+
+// shortList keeps the first entries of a list so a report fits in a message
+func shortList(lang string, items []string) []string {
+	const limit = 15
+	if len(items) <= limit {
+		return items
+	}
+	return append(items[:limit:limit], i18n.T(lang, "and %d more", len(items)-limit))
+}
+
+// applyImport stores a checked backup, entries in conflict are only written when overwrite is set
+func applyImport(plan importPlan, overwrite bool) (int, int) {
+	applied, skipped := 0, 0
+	write := func(conflict string, store func() error) {
+		if plan.Conflicts[conflict] && !overwrite {
+			skipped++
+			return
+		}
+		if err := store(); err != nil {
+			skipped++
+			return
+		}
+		applied++
+	}
+
+	for _, chatId := range plan.Backup.Connections {
+		db.ConnectId(plan.UserId, chatId)
+	}
+	write("settings", func() error {
+		if err := db.SetUserSettings(plan.Backup.Settings); err != nil {
+			return err
+		}
+		// The language is cached apart from the settings, it has to follow the imported one
+		i18n.SetLang(plan.UserId, plan.Backup.Settings.Language)
+		return nil
+	})
+	for _, post := range plan.Backup.Posts {
+		write("post:"+post.PostId, func() error { return db.ReplacePost(post) })
+	}
+	for _, group := range plan.Backup.Groups {
+		write("group:"+group.Name, func() error { return db.SetChatGroup(plan.UserId, group.Name, group.ChatIds) })
+	}
+	for _, schedule := range plan.Backup.Schedules {
+		write("schedule:"+schedule.ScheduleId, func() error { return db.ReplaceSchedule(schedule) })
+	}
+	return applied, skipped
+}
+
+func importCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.Update.CallbackQuery
+	action, key, _ := strings.Cut(strings.TrimPrefix(query.Data, "import."), ".")
+
+	importPlans.Lock()
+	plan, ok := importPlans.plans[key]
+	if ok && plan.UserId == query.From.Id {
+		delete(importPlans.plans, key)
+	}
+	importPlans.Unlock()
+
+	if !ok || plan.UserId != query.From.Id {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "This import has expired. Please use /import again."), ShowAlert: true})
+		return nil
+	}
+
+	text := tr(ctx, "Import cancelled, nothing was changed.")
+	if action != "cancel" {
+		applied, skipped := applyImport(plan, action == "overwrite")
+		text = tr(ctx, "✅ Import finished: %d connections added, %d entries imported, %d skipped.", len(plan.Backup.Connections), applied, skipped)
+	}
+
+	_, _ = query.Answer(b, nil)
+	_, _, err := b.EditMessageText(text, &gotgbot.EditMessageTextOpts{
+		ChatId:    query.Message.GetChat().Id,
+		MessageId: query.Message.GetMessageId(),
+		ParseMode: gotgbot.ParseModeHTML,
+	})
+	return err
+}
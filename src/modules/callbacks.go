@@ -3,6 +3,8 @@ package modules
 import (
 	"AshokShau/channelManager/src/db"
 	helpers2 "AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -15,11 +17,12 @@ func sendPostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
 	query := ctx.Update.CallbackQuery
 	user := query.From
+	lang := i18n.Lang(ctx.EffectiveUser)
 
-	chatIds := isConnected(b, ctx, query.From.Id)
+	chatIds := isConnected(b, ctx, query.From.Id, onlyAdmins.CanPostMessages)
 	if chatIds == nil {
 		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-			Text:      "⚠️ You are not connected to any channels.\nPlease connect to a channel and try again. Bye 👋",
+			Text:      tr(ctx, "⚠️ You are not connected to any channels.\nPlease connect to a channel and try again. Bye 👋"),
 			ShowAlert: true,
 		})
 		time.Sleep(10 * time.Millisecond)
@@ -31,79 +34,74 @@ func sendPostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	post, err := db.GetPost(postId)
 	if err != nil || post == nil {
 		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-			Text:      "❌ Post not found.\nPlease verify the post and try again. Bye 👋",
+			Text:      tr(ctx, "❌ Post not found.\nPlease verify the post and try again. Bye 👋"),
 			ShowAlert: true,
 		})
 		_, _ = query.Message.Delete(b, nil)
 		return err
 	}
 
-	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-		Text:      "📤 Sending post to connected chats...\nThis may take some time.",
-		ShowAlert: true,
-	})
-
-	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers2.BuildKeyboard(post.Buttons)}
-	if keyboard.InlineKeyboard == nil {
-		keyboard.InlineKeyboard = make([][]gotgbot.InlineKeyboardButton, 0)
-	}
-	newPostId := helpers2.GenerateUniqueString()
-	var successChats, failedChats []string
-	successCount, failedCount := 0, 0
-
-	userSetting := db.GetUserSettings(user.Id)
-	for i, chatId := range chatIds {
-		// Pause for rate-limiting if needed
-		if (successCount+failedCount)%23 == 0 && i > 0 {
-			log.Printf("[sendPostCallback] Reached batch limit, pausing for 1 minute...")
-			time.Sleep(1 * time.Minute)
-			successCount = 0
-			failedCount = 0
+	// deliver sends the checked post to the checked chats, also when a policy report is overridden later
+	deliver := func(b *gotgbot.Bot) error {
+		newPostId := helpers2.GenerateUniqueString()
+		var successChats, failedChats, pinFailed []string
+		for _, delivery := range deliverPost(b, nil, user.Id, newPostId, post, chatIds) {
+			chatId := delivery.ChatId
+			if delivery.Err != nil {
+				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
+				continue
+			}
+
+			messageId := delivery.Message.MessageId
+			successChats = append(successChats, fmt.Sprintf("<a href='%s'>%d</a> \n(<code>!del %d %d</code>)", delivery.Message.GetLink(), chatId, chatId, messageId))
+			if delivery.PinErr != nil {
+				pinFailed = append(pinFailed, pinFailure(lang, chatId, delivery.PinErr))
+			}
 		}
 
-		message, err := helpers2.PostEnumFuncMap[post.MsgType](b, ctx, chatId, post.FilterReply, post.FileID, &keyboard, userSetting)
-		if err != nil {
-			log.Printf("Failed to send post to chat %d: %v", chatId, err)
-			failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-			failedCount++
-		} else {
-			messageId := message.MessageId
-			successChats = append(successChats, fmt.Sprintf("<a href='%s'>%d</a> \n(<code>!del %d %d</code>)", message.GetLink(), chatId, chatId, messageId))
-			_, _ = db.AddPost(newPostId, user.Id, chatId, messageId, post.MsgType, post.FileID, post.Buttons, post.FilterReply)
-			successCount++
+		go func() {
+			_ = db.RemovePost(postId)
+		}()
+
+		// Build response text
+		var responseText strings.Builder
+		responseText.WriteString(i18n.T(lang, "<b>📋 Post Result Summary:</b>\n\n"))
+
+		if len(successChats) > 0 {
+			responseText.WriteString(i18n.T(lang, "✅ <b>Successfully sent to %d chats:</b>\n", len(successChats)))
+			responseText.WriteString(strings.Join(successChats, "\n") + "\n\n")
 		}
-	}
 
-	go func() {
-		_ = db.RemovePost(postId)
-	}()
+		if len(failedChats) > 0 {
+			responseText.WriteString(i18n.T(lang, "❌ <b>Failed to send to %d chats:</b>\n", len(failedChats)))
+			responseText.WriteString(strings.Join(failedChats, "\n") + "\n")
+		}
 
-	// Build response text
-	var responseText strings.Builder
-	responseText.WriteString("<b>📋 Post Result Summary:</b>\n\n")
+		responseText.WriteString(pinSummary(lang, pinFailed))
+		responseText.WriteString(i18n.T(lang, "\n<b>🆔 PostId:</b> <code>%s</code>", newPostId))
 
-	if len(successChats) > 0 {
-		responseText.WriteString(fmt.Sprintf("✅ <b>Successfully sent to %d chats:</b>\n", len(successChats)))
-		responseText.WriteString(strings.Join(successChats, "\n") + "\n\n")
+		_, err := msg.Reply(b, responseText.String(), &gotgbot.SendMessageOpts{ParseMode: "HTML", ReplyMarkup: helpers2.PostButton(lang, newPostId), ReplyParameters: &gotgbot.ReplyParameters{AllowSendingWithoutReply: true}})
+		time.Sleep(10 * time.Millisecond)
+		_, _ = msg.Delete(b, nil)
+		return err
 	}
 
-	if len(failedChats) > 0 {
-		responseText.WriteString(fmt.Sprintf("❌ <b>Failed to send to %d chats:</b>\n", len(failedChats)))
-		responseText.WriteString(strings.Join(failedChats, "\n") + "\n")
+	if !policyGate(b, ctx, user.Id, chatIds, post.FilterReply, post.Buttons, deliver) {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "🚫 This post breaks your content policies.")})
+		return nil
 	}
 
-	responseText.WriteString(fmt.Sprintf("\n<b>🆔 PostId:</b> <code>%s</code>", newPostId))
-
-	_, err = msg.Reply(b, responseText.String(), &gotgbot.SendMessageOpts{ParseMode: "HTML", ReplyMarkup: helpers2.PostButton(newPostId), ReplyParameters: &gotgbot.ReplyParameters{AllowSendingWithoutReply: true}})
-	time.Sleep(10 * time.Millisecond)
-	_, _ = msg.Delete(b, nil)
-	return err
+	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
+		Text:      tr(ctx, "📤 Sending post to connected chats...\nThis may take some time."),
+		ShowAlert: true,
+	})
+	return deliver(b)
 }
 
 func deletePostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
 	query := ctx.Update.CallbackQuery
-	chatIds := isConnected(b, ctx, query.From.Id)
+	chatIds := isConnected(b, ctx, query.From.Id, onlyAdmins.CanDeleteMessages)
 	if chatIds == nil {
 		return nil
 	}
@@ -115,13 +113,13 @@ func deletePostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	if post == nil {
-		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Post not found. \nBye 👋", ShowAlert: true})
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "Post not found. \nBye 👋"), ShowAlert: true})
 		_, _ = query.Message.Delete(b, nil)
 		return nil
 	}
 
 	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{
-		Text:      "📤 Deleting post from connected chats...\nThis may take some time.",
+		Text:      tr(ctx, "📤 Deleting post from connected chats...\nThis may take some time."),
 		ShowAlert: true,
 	})
 	postChats := post.Chats
@@ -131,6 +129,7 @@ func deletePostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 			log.Printf("deletePost: Error deleting message from ChatID %d: %v", chat.ChatId, err)
 			continue
 		}
+		emitDeleted(post.UserId, postId, chat.ChatId, chat.MsgId)
 		time.Sleep(200 * time.Millisecond)
 	}
 
@@ -142,7 +141,8 @@ func deletePostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 func repostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
 	query := ctx.Update.CallbackQuery
-	chatIds := isConnected(b, ctx, query.From.Id)
+	lang := i18n.Lang(ctx.EffectiveUser)
+	chatIds := isConnected(b, ctx, query.From.Id, onlyAdmins.CanPostMessages, onlyAdmins.CanDeleteMessages)
 	if chatIds == nil {
 		return nil
 	}
@@ -150,79 +150,103 @@ func repostCallback(b *gotgbot.Bot, ctx *ext.Context) error {
 	postId := strings.Split(query.Data, ".")[1]
 	post, err := db.GetPost(postId)
 	if err != nil || post == nil {
-		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "Post not found.\nPlease try again. bye 👋", ShowAlert: true})
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "Post not found.\nPlease try again. bye 👋"), ShowAlert: true})
 		_, _ = query.Message.Delete(b, nil)
 		return err
 	}
 
-	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers2.BuildKeyboard(post.Buttons)}
-	if keyboard.InlineKeyboard == nil {
-		keyboard.InlineKeyboard = make([][]gotgbot.InlineKeyboardButton, 0)
-	}
-
-	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: "📤 Reposting post to connected chats...\nThis may take some time.", ShowAlert: true})
+	// deliver reposts the checked post to the checked chats, also when a policy report is overridden later
+	deliver := func(b *gotgbot.Bot) error {
+		// delete old post
+		for _, chat := range post.Chats {
+			_, err := b.DeleteMessage(chat.ChatId, chat.MsgId, nil)
+			if err != nil {
+				log.Printf("repost: Error deleting message from ChatID %d: %v", chat.ChatId, err)
+				continue
+			}
+			emitDeleted(post.UserId, postId, chat.ChatId, chat.MsgId)
+			time.Sleep(100 * time.Millisecond)
+		}
 
-	// delete old post
-	for _, chat := range post.Chats {
-		_, err = b.DeleteMessage(chat.ChatId, chat.MsgId, nil)
-		if err != nil {
-			log.Printf("repost: Error deleting message from ChatID %d: %v", chat.ChatId, err)
-			continue
+		// send new post
+		newPostId := helpers2.GenerateUniqueString()
+		var successChats, failedChats, pinFailed []string
+		for _, delivery := range deliverPost(b, nil, query.From.Id, newPostId, post, chatIds) {
+			chatId := delivery.ChatId
+			if delivery.Err != nil {
+				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
+				continue
+			}
+
+			message := delivery.Message
+			successChats = append(successChats, fmt.Sprintf("<a href='%s'>%d</a> \n(<code>!del %d %d</code>)", message.GetLink(), chatId, chatId, message.MessageId))
+			if delivery.PinErr != nil {
+				pinFailed = append(pinFailed, pinFailure(lang, chatId, delivery.PinErr))
+			}
 		}
-		time.Sleep(100 * time.Millisecond)
-	}
-
-	// send new post
-	newPostId := helpers2.GenerateUniqueString()
-	var successChats, failedChats []string
-	successCount := 0
-	failedCount := 0
-	userSetting := db.GetUserSettings(query.From.Id)
-	for i, chatId := range chatIds {
-		// Check if we need to sleep before processing the next batch
-		if (successCount+failedCount)%23 == 0 && i > 0 {
-			log.Printf("[repostCallback] Sleeping for 1 minute before processing the next batch...")
-			time.Sleep(1 * time.Minute)
-			successCount = 0
-			failedCount = 0
+
+		// prepare the response text
+		var responseText strings.Builder
+		responseText.WriteString(i18n.T(lang, "<b>Post Result Summary:</b>\n\n"))
+
+		if len(successChats) > 0 {
+			responseText.WriteString(i18n.T(lang, "✅ <b>Successfully sent to:</b>\n"))
+			responseText.WriteString(strings.Join(successChats, "\n") + "\n\n")
 		}
 
-		// Attempt to send the message
-		message, err := helpers2.PostEnumFuncMap[post.MsgType](b, ctx, chatId, post.FilterReply, post.FileID, &keyboard, userSetting)
-		if err != nil {
-			failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-			failedCount++
-		} else {
-			// Save the post in the database
-			_, _ = db.AddPost(newPostId, msg.From.Id, chatId, message.MessageId, post.MsgType, post.FileID, post.Buttons, post.FilterReply)
-			successChats = append(successChats, fmt.Sprintf("<a href='%s'>%d</a> \n(<code>!del %d %d</code>)", message.GetLink(), chatId, chatId, message.MessageId))
-			successCount++
+		if len(failedChats) > 0 {
+			responseText.WriteString(i18n.T(lang, "❌ <b>Failed to send to:</b>\n"))
+			responseText.WriteString(strings.Join(failedChats, "\n") + "\n")
 		}
-	}
 
-	// prepare the response text
-	var responseText strings.Builder
-	responseText.WriteString("<b>Post Result Summary:</b>\n\n")
+		responseText.WriteString(pinSummary(lang, pinFailed))
+		responseText.WriteString(i18n.T(lang, "<b>PostId:</b> <code>%s</code>", newPostId))
 
-	if len(successChats) > 0 {
-		responseText.WriteString("✅ <b>Successfully sent to:</b>\n")
-		responseText.WriteString(strings.Join(successChats, "\n") + "\n\n")
+		_, _ = msg.Reply(b, responseText.String(), &gotgbot.SendMessageOpts{
+			ParseMode:          "HTML",
+			ReplyMarkup:        helpers2.PostButton(lang, newPostId),
+			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+			ReplyParameters:    &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
+		})
+
+		_, _ = msg.Delete(b, nil)
+		return nil
 	}
 
-	if len(failedChats) > 0 {
-		responseText.WriteString("❌ <b>Failed to send to:</b>\n")
-		responseText.WriteString(strings.Join(failedChats, "\n") + "\n")
+	if !policyGate(b, ctx, query.From.Id, chatIds, post.FilterReply, post.Buttons, deliver) {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "🚫 This post breaks your content policies.")})
+		return nil
 	}
 
-	responseText.WriteString(fmt.Sprintf("<b>PostId:</b> <code>%s</code>", newPostId))
+	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "📤 Reposting post to connected chats...\nThis may take some time."), ShowAlert: true})
+	return deliver(b)
+}
 
-	_, _ = msg.Reply(b, responseText.String(), &gotgbot.SendMessageOpts{
-		ParseMode:          "HTML",
-		ReplyMarkup:        helpers2.PostButton(newPostId),
-		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
-		ReplyParameters:    &gotgbot.ReplyParameters{AllowSendingWithoutReply: true},
-	})
+func alertCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.Update.CallbackQuery
+	if query.Message == nil {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "This alert is not available here.")})
+		return nil
+	}
 
-	_, _ = msg.Delete(b, nil)
+	chatId := query.Message.GetChat().Id
+	msgId := query.Message.GetMessageId()
+	post, err := db.GetPostByMessage(chatId, msgId)
+	if err == nil && post == nil && query.Message.GetChat().Type == "private" {
+		// Post previews from !create are stored under the bot's id
+		post, err = db.GetPostByMessage(b.Id, msgId)
+	}
+	if err != nil || post == nil {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "This alert is not available here.")})
+		return err
+	}
+
+	index := helpers2.ToInt(strings.Split(query.Data, ".")[1])
+	if index < 0 || index >= len(post.Buttons) || post.Buttons[index].Type != db.ButtonAlert {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "This alert is no longer available.")})
+		return nil
+	}
+
+	_, err = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: post.Buttons[index].Url, ShowAlert: true})
 	return err
 }
@@ -0,0 +1,171 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// maxCheckRows is the number of chats the health report shows in a message, larger reports are sent as a file
+const maxCheckRows = 10
+
+// checkedRights are the rights of the bot the health report shows, with their short names.
+// Optional rights are shown but a chat lacking them is still healthy.
+var checkedRights = []struct {
+	right    onlyAdmins.Right
+	name     string
+	optional bool
+}{
+	{onlyAdmins.CanPostMessages, i18n.N("post"), false},
+	{onlyAdmins.CanEditMessages, i18n.N("edit"), false},
+	{onlyAdmins.CanDeleteMessages, i18n.N("delete"), false},
+	{onlyAdmins.CanPinMessages, i18n.N("pin"), true},
+}
+
+// chatHealth is one row of the health report
+type chatHealth struct {
+	chatId     int64
+	title      string
+	reachable  bool
+	userAdmin  bool
+	botAdmin   bool
+	has, lacks []string
+	missing    int // lacked rights that are not optional
+	members    int64
+}
+
+// healthy reports whether posts can be sent, edited and deleted in the chat
+func (h chatHealth) healthy() bool {
+	return h.reachable && h.userAdmin && h.botAdmin && h.missing == 0
+}
+
+// checkChat reloads the chat and its admins to report the state of a connection
+func checkChat(b *gotgbot.Bot, lang string, chatId, userId int64) chatHealth {
+	health := chatHealth{chatId: chatId, members: -1}
+
+	getChat := onlyAdmins.LoadChatCache(b, chatId)
+	if !getChat.Cached {
+		return health
+	}
+	health.reachable = true
+	health.title = getChat.ChatInfo.Title
+
+	if count, err := b.GetChatMemberCount(chatId, nil); err == nil {
+		health.members = count
+	}
+
+	admins := onlyAdmins.LoadAdminCache(b, chatId)
+	if !admins.Cached {
+		return health
+	}
+	_, health.userAdmin = admins.Admin(userId)
+	_, health.botAdmin = admins.Admin(b.Id)
+	if !health.botAdmin {
+		return health
+	}
+
+	for _, checked := range checkedRights {
+		if _, missing := onlyAdmins.MissingRight(chatId, b.Id, checked.right); missing == "" {
+			health.has = append(health.has, i18n.T(lang, checked.name))
+		} else {
+			health.lacks = append(health.lacks, i18n.T(lang, checked.name))
+			if !checked.optional {
+				health.missing++
+			}
+		}
+	}
+	return health
+}
+
+// healthTable formats the health report as a plain text table
+func healthTable(lang string, rows []chatHealth) string {
+	mark := func(ok bool) string {
+		if ok {
+			return "✓"
+		}
+		return "✗"
+	}
+	list := func(items []string) string {
+		if len(items) == 0 {
+			return "-"
+		}
+		return strings.Join(items, ",")
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, i18n.T(lang, "Chat ID\tTitle\tReachable\tYou admin\tBot admin\tBot has\tBot lacks\tMembers"))
+	for _, row := range rows {
+		title := []rune(row.title)
+		if len(title) > 20 {
+			title = append(title[:19], '…')
+		}
+		members := "-"
+		if row.members >= 0 {
+			members = fmt.Sprint(row.members)
+		}
+		if !row.reachable {
+			_, _ = fmt.Fprintf(w, "%d\t-\t%s\t-\t-\t-\t-\t-\n", row.chatId, mark(false))
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.chatId, string(title), mark(true), mark(row.userAdmin), mark(row.botAdmin), list(row.has), list(row.lacks), members)
+	}
+	_ = w.Flush()
+	return buf.String()
+}
+
+func checkConnections(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	chatIds := db.Connection(msg.From.Id).ChatIds
+	if len(chatIds) == 0 {
+		_, err := msg.Reply(b, tr(ctx, "⚠️ You are not connected to any chats.\n\nUse <code>/add chat_id</code> to connect."), helpers.Shtml())
+		return err
+	}
+
+	reply, err := msg.Reply(b, tr(ctx, "Checking %d chats, this may take a moment...", len(chatIds)), helpers.Shtml())
+	if err != nil {
+		return err
+	}
+
+	lang := i18n.Lang(msg.From)
+	rows := make([]chatHealth, 0, len(chatIds))
+	healthy := 0
+	for _, chatId := range chatIds {
+		row := checkChat(b, lang, chatId, msg.From.Id)
+		if row.healthy() {
+			healthy++
+		}
+		rows = append(rows, row)
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	table := healthTable(lang, rows)
+	summary := tr(ctx, "<b>%d of %d chats are healthy.</b>", healthy, len(rows))
+	if len(rows) <= maxCheckRows {
+		_, _, err = reply.EditText(b, fmt.Sprintf("<pre>%s</pre>\n%s", html.EscapeString(table), summary), &gotgbot.EditMessageTextOpts{ParseMode: gotgbot.ParseModeHTML})
+		return err
+	}
+
+	_, _ = reply.Delete(b, nil)
+	name := fmt.Sprintf("check-%s.txt", time.Now().Format("2006-01-02"))
+	_, err = b.SendDocument(msg.Chat.Id, gotgbot.InputFileByReader(name, strings.NewReader(table)), &gotgbot.SendDocumentOpts{
+		Caption:         summary,
+		ParseMode:       gotgbot.ParseModeHTML,
+		ReplyParameters: &gotgbot.ReplyParameters{MessageId: msg.MessageId, AllowSendingWithoutReply: true},
+	})
+	return err
+}
@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/config"
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// RegisterRoutes adds the HTTP endpoints of the bot to mux, they are served with the webhook or on their own when polling.
+func RegisterRoutes(mux *http.ServeMux, b *gotgbot.Bot) {
+	registerApiRoutes(mux, b)
+	if config.ClickTrackingUrl != "" {
+		mux.HandleFunc("GET /c/{token}", clickRedirect)
+		helpers.EnableClickTracking()
+		log.Printf("Click tracking enabled on %s", config.ClickTrackingUrl)
+	}
+}
+
+// clickRedirect records a click on a tracked link and redirects to the button url
+func clickRedirect(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	link, err := db.GetLink(token)
+	if err != nil || link == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	go db.AddClick(token)
+	http.Redirect(w, r, link.Url, http.StatusFound)
+}
+
+func clicks(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		_, err := msg.Reply(b, tr(ctx, "Please provide a PostId to get the button clicks.\nUsage: <code>!clicks PostId</code>"), helpers.Shtml())
+		return err
+	}
+
+	post, err := db.GetPost(args[0])
+	if err != nil || post == nil || post.UserId != msg.From.Id {
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or error retrieving post."), helpers.Shtml())
+		return err
+	}
+
+	links, err := db.ListLinks(post.PostId)
+	if err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error retrieving button clicks."), helpers.Shtml())
+		return err
+	}
+
+	if len(links) == 0 {
+		_, err = msg.Reply(b, tr(ctx, "No tracked buttons found for this post.\nButton clicks are only tracked for posts sent while click tracking is enabled."), helpers.Shtml())
+		return err
+	}
+
+	// Group the links by chat, keeping the button order of the post
+	byChat := make(map[int64][]db.Link)
+	var chatIds []int64
+	for _, link := range links {
+		if _, ok := byChat[link.ChatId]; !ok {
+			chatIds = append(chatIds, link.ChatId)
+		}
+		byChat[link.ChatId] = append(byChat[link.ChatId], link)
+	}
+
+	var text strings.Builder
+	var total int64
+	text.WriteString(tr(ctx, "<b>📊 Button clicks for</b> <code>%s</code>\n\n", post.PostId))
+	for _, chatId := range chatIds {
+		chatLinks := byChat[chatId]
+		sort.Slice(chatLinks, func(i, j int) bool { return chatLinks[i].Button < chatLinks[j].Button })
+
+		title := fmt.Sprint(chatId)
+		if getChat := onlyAdmins.GetChatCache(chatId); getChat.Cached {
+			title = getChat.ChatInfo.Title
+		}
+
+		text.WriteString(fmt.Sprintf("<b>%s</b> (<code>%d</code>)\n", html.EscapeString(title), chatId))
+		for _, link := range chatLinks {
+			text.WriteString(fmt.Sprintf("• %s: <code>%d</code>\n", html.EscapeString(link.Name), link.Clicks))
+			total += link.Clicks
+		}
+		text.WriteString("\n")
+	}
+	text.WriteString(tr(ctx, "<b>Total clicks:</b> <code>%d</code>", total))
+
+	_, err = msg.Reply(b, text.String(), helpers.Shtml())
+	return err
+}
@@ -0,0 +1,198 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"fmt"
+	tgmd2html "github.com/PaulSonOfLars/gotg_md2html"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commentTimeout is how long the bot waits for a channel post to be forwarded to its discussion group
+const commentTimeout = 2 * time.Minute
+
+type pendingComment struct {
+	PostId  string
+	Comment string
+}
+
+// pendingComments holds comments waiting for their post's automatic forward, and
+// automatic forwards that arrived before the send of their post returned.
+var pendingComments = struct {
+	sync.Mutex
+	comments map[string]pendingComment
+	forwards map[string]*gotgbot.Message
+}{comments: make(map[string]pendingComment), forwards: make(map[string]*gotgbot.Message)}
+
+func commentKey(channelId, msgId int64) string {
+	return fmt.Sprintf("%d:%d", channelId, msgId)
+}
+
+// queueComment posts the comment under a channel post once it shows up in the channel's linked discussion group
+func queueComment(b *gotgbot.Bot, channelId, msgId int64, postId, comment string) {
+	if comment == "" {
+		return
+	}
+
+	getChat := onlyAdmins.GetChatCache(channelId)
+	if !getChat.Cached || getChat.LinkedChatId == 0 {
+		getChat = onlyAdmins.LoadChatCache(b, channelId)
+	}
+	if getChat.ChatInfo.Type != "channel" || getChat.LinkedChatId == 0 {
+		return
+	}
+
+	key := commentKey(channelId, msgId)
+	pendingComments.Lock()
+	if forward, ok := pendingComments.forwards[key]; ok {
+		delete(pendingComments.forwards, key)
+		pendingComments.Unlock()
+		sendComment(b, forward, pendingComment{PostId: postId, Comment: comment})
+		return
+	}
+	pendingComments.comments[key] = pendingComment{PostId: postId, Comment: comment}
+	pendingComments.Unlock()
+
+	time.AfterFunc(commentTimeout, func() {
+		pendingComments.Lock()
+		defer pendingComments.Unlock()
+		if _, ok := pendingComments.comments[key]; ok {
+			delete(pendingComments.comments, key)
+			log.Printf("[comments] No automatic forward of %d in chat %d, comment dropped", msgId, getChat.LinkedChatId)
+		}
+	})
+}
+
+func isAutomaticForward(msg *gotgbot.Message) bool {
+	return msg.IsAutomaticForward && msg.ForwardOrigin != nil
+}
+
+// automaticForward replies to channel posts in discussion groups with their queued comment
+func automaticForward(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	origin := msg.ForwardOrigin.MergeMessageOrigin()
+	if origin.Type != "channel" || origin.Chat == nil {
+		return nil
+	}
+
+	key := commentKey(origin.Chat.Id, origin.MessageId)
+	pendingComments.Lock()
+	comment, ok := pendingComments.comments[key]
+	if ok {
+		delete(pendingComments.comments, key)
+		pendingComments.Unlock()
+		sendComment(b, msg, comment)
+		return nil
+	}
+
+	// The forward can arrive before the send returns, keep it around for queueComment
+	pendingComments.forwards[key] = msg
+	pendingComments.Unlock()
+	time.AfterFunc(commentTimeout, func() {
+		pendingComments.Lock()
+		delete(pendingComments.forwards, key)
+		pendingComments.Unlock()
+	})
+	return nil
+}
+
+// sendComment replies to the forwarded post and records the comment on the post
+func sendComment(b *gotgbot.Bot, forward *gotgbot.Message, comment pendingComment) {
+	message, err := b.SendMessage(forward.Chat.Id, comment.Comment, &gotgbot.SendMessageOpts{
+		ParseMode:          gotgbot.ParseModeHTML,
+		ReplyParameters:    &gotgbot.ReplyParameters{MessageId: forward.MessageId, AllowSendingWithoutReply: true},
+		LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+	})
+	if err != nil {
+		log.Printf("[comments] Failed to comment in chat %d: %v", forward.Chat.Id, err)
+		return
+	}
+
+	_ = db.AddPostComment(comment.PostId, forward.Chat.Id, message.MessageId)
+}
+
+func setComment(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		_, err := msg.Reply(b, tr(ctx, "Please provide a PostId and the comment to post under it in linked discussion groups.\nUsage: <code>!comment PostId text</code>\nUse <code>!comment PostId off</code> to remove it."), helpers.Shtml())
+		return err
+	}
+
+	post, err := db.GetPost(args[0])
+	if err != nil || post == nil || post.UserId != msg.From.Id {
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or error retrieving post."), helpers.Shtml())
+		return err
+	}
+
+	if len(args) == 1 {
+		text := tr(ctx, "This post has no comment.")
+		if post.Comment != "" {
+			text = tr(ctx, "Current comment:\n\n") + post.Comment
+		}
+		_, err = msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	comment := ""
+	if args[1] != "off" {
+		raw := msg.OriginalMDV2()
+		raw = raw[strings.Index(raw, args[0])+len(args[0]):]
+		comment = strings.TrimSpace(tgmd2html.MD2HTMLV2(raw))
+		if len(comment) > 4096 {
+			_, err = msg.Reply(b, tr(ctx, "Your comment is %d characters long. The maximum length for text is 4096.", len(comment)), helpers.Shtml())
+			return err
+		}
+	}
+
+	if err = db.SetPostComment(post.PostId, comment); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the comment."), helpers.Shtml())
+		return err
+	}
+
+	// Update the comments that were already posted
+	edited := 0
+	for _, chat := range post.Chats {
+		if !chat.Comment {
+			continue
+		}
+
+		if comment == "" {
+			_, err = b.DeleteMessage(chat.ChatId, chat.MsgId, nil)
+		} else {
+			_, _, err = b.EditMessageText(comment, &gotgbot.EditMessageTextOpts{
+				ChatId:             chat.ChatId,
+				MessageId:          chat.MsgId,
+				ParseMode:          gotgbot.ParseModeHTML,
+				LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true},
+			})
+		}
+		if err != nil {
+			log.Printf("[comments] Failed to update comment in chat %d: %v", chat.ChatId, err)
+			continue
+		}
+		edited++
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	text := tr(ctx, "Comment saved, it will be posted under this post in linked discussion groups the next time it is sent.")
+	if comment == "" {
+		text = tr(ctx, "Comment removed.")
+	}
+	if edited > 0 {
+		text += tr(ctx, "\nUpdated %d posted comments.", edited)
+	}
+
+	_, err = msg.Reply(b, text, helpers.Shtml())
+	return err
+}
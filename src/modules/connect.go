@@ -4,19 +4,89 @@ import (
 	"AshokShau/channelManager/src/config"
 	"AshokShau/channelManager/src/db"
 	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
 	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
+	"html"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 )
 
-func isConnected(b *gotgbot.Bot, ctx *ext.Context, userId int64) []int64 {
+// lostConnection disconnects a chat that failed the checks of isConnected and reports it
+func lostConnection(errorMessage *strings.Builder, userId, chatId int64, reason string) {
+	db.DisconnectId(userId, chatId)
+	errorMessage.WriteString(fmt.Sprintf("<code>%d</code> (%s)\n", chatId, trUser(userId, reason)))
+	emitEvent(userId, eventConnectionLost, map[string]any{"chat_id": chatId, "reason": reason})
+}
+
+// rightFailures are the reasons shown when the user, or the bot, lacks a right an operation needs
+var rightFailures = map[onlyAdmins.Right][2]string{
+	onlyAdmins.NotAdmin:          {i18n.N("You are not an admin"), i18n.N("I am not an admin")},
+	onlyAdmins.CanPostMessages:   {i18n.N("You are missing the right to post messages"), i18n.N("I am missing the right to post messages")},
+	onlyAdmins.CanEditMessages:   {i18n.N("You are missing the right to edit messages"), i18n.N("I am missing the right to edit messages")},
+	onlyAdmins.CanDeleteMessages: {i18n.N("You are missing the right to delete messages"), i18n.N("I am missing the right to delete messages")},
+	onlyAdmins.CanPinMessages:    {i18n.N("You are missing the right to pin messages"), i18n.N("I am missing the right to pin messages")},
+}
+
+// missingRights checks that both the user and the bot have the rights in a chat whose admins are cached.
+// It returns an empty string, or the reason naming the first missing right.
+func missingRights(b *gotgbot.Bot, chatId, userId int64, rights []onlyAdmins.Right) string {
+	if len(rights) == 0 {
+		return ""
+	}
+	if _, missing := onlyAdmins.MissingRight(chatId, userId, rights...); missing != "" {
+		return rightFailures[missing][0]
+	}
+	if _, missing := onlyAdmins.MissingRight(chatId, b.Id, rights...); missing != "" {
+		return rightFailures[missing][1]
+	}
+	return ""
+}
+
+// chatRefusal is a chat an operation skipped, with the untranslated reason
+type chatRefusal struct {
+	ChatId int64
+	Reason string
+}
+
+// refusalLines formats refused chats with their reasons as HTML
+func refusalLines(lang string, refused []chatRefusal) []string {
+	lines := make([]string, 0, len(refused))
+	for _, refusal := range refused {
+		lines = append(lines, fmt.Sprintf("<code>%d</code> (%s)", refusal.ChatId, i18n.T(lang, refusal.Reason)))
+	}
+	return lines
+}
+
+// permittedChats checks that the user is still an admin of each chat and that both the user and the bot
+// have the rights, for operations that run without a command. It returns the chats that pass and the refused ones.
+func permittedChats(b *gotgbot.Bot, userId int64, chatIds []int64, rights ...onlyAdmins.Right) ([]int64, []chatRefusal) {
+	var permitted []int64
+	var refused []chatRefusal
+	for _, chatId := range chatIds {
+		_, failure := checkChatAdmin(b, chatId, userId)
+		if failure == "" {
+			failure = missingRights(b, chatId, userId, rights)
+		}
+		if failure != "" {
+			refused = append(refused, chatRefusal{ChatId: chatId, Reason: failure})
+			continue
+		}
+		permitted = append(permitted, chatId)
+	}
+	return permitted, refused
+}
+
+// isConnected returns the connected chats of a user after checking that they are still admins there,
+// chats where they are not are disconnected. If rights are given, chats where the user or the bot lacks
+// one of them are left out of the operation and listed in a reply, without disconnecting them.
+func isConnected(b *gotgbot.Bot, ctx *ext.Context, userId int64, rights ...onlyAdmins.Right) []int64 {
 	msg := ctx.EffectiveMessage
 
 	// If the chat is not private, return the chat ID
@@ -30,12 +100,14 @@ func isConnected(b *gotgbot.Bot, ctx *ext.Context, userId int64) []int64 {
 
 	// If no connected chats, prompt the user to connect
 	if connectedChats == nil || len(connectedChats) == 0 {
-		text := "⚠️ You are not connected to any chats.\n\nUse <code>/add chat_id</code> to connect."
+		text := tr(ctx, "⚠️ You are not connected to any chats.\n\nUse <code>/add chat_id</code> to connect.")
 		_, _ = msg.Reply(b, text, helpers.Shtml())
 		return nil
 	}
 
 	var errorMessage strings.Builder
+	var permitted []int64
+	var refused []chatRefusal
 
 	// Validate connected chats
 	for _, chatId := range connectedChats {
@@ -48,44 +120,31 @@ func isConnected(b *gotgbot.Bot, ctx *ext.Context, userId int64) []int64 {
 
 		// If chat still not cached, disconnect and log error
 		if !getChat.Cached {
-			db.DisconnectId(userId, chatId)
-			errorMessage.WriteString(fmt.Sprintf("<code>%d</code> (Chat not found)\n", chatId))
+			lostConnection(&errorMessage, userId, chatId, i18n.N("Chat not found"))
 			continue
 		}
 
-		// Check if user is an admin
+		// Load the admin list if it is not cached
 		userCached, _ := onlyAdmins.IsUserAdmin(chatId, userId)
 		if !userCached {
 			time.Sleep(20 * time.Millisecond)
 			if reloaded := onlyAdmins.LoadAdminCache(b, chatId); !reloaded.Cached {
-				db.DisconnectId(userId, chatId)
-				errorMessage.WriteString(fmt.Sprintf("<code>%d</code> (Failed to verify admin status)\n", chatId))
+				lostConnection(&errorMessage, userId, chatId, i18n.N("Failed to verify admin status"))
 				continue
 			}
 		}
 
 		// Verify admin status of the user
-		userCached, isAdmin := onlyAdmins.IsUserAdmin(chatId, userId)
-		if userCached && !isAdmin {
-			db.DisconnectId(userId, chatId)
-			errorMessage.WriteString(fmt.Sprintf("<code>%d</code> (You are not an admin)\n", chatId))
-			continue
-		}
-
-		if !isAdmin {
-			db.DisconnectId(userId, chatId)
-			errorMessage.WriteString(fmt.Sprintf("<code>%d</code> (You are not an admin)\n", chatId))
+		if _, isAdmin := onlyAdmins.IsUserAdmin(chatId, userId); !isAdmin {
+			lostConnection(&errorMessage, userId, chatId, i18n.N("You are not an admin"))
 			continue
 		}
 
-		// Verify bot's admin status
-		_, isBotAdmin := onlyAdmins.IsUserAdmin(chatId, b.Id)
-		if !isBotAdmin {
-			if reloaded := onlyAdmins.LoadAdminCache(b, chatId); !reloaded.Cached {
-				db.DisconnectId(userId, chatId)
-				errorMessage.WriteString(fmt.Sprintf("<code>%d</code> (Failed to verify admin status)\n", chatId))
-				continue
-			}
+		// Verify the rights of the user and the bot for this operation
+		if failure := missingRights(b, chatId, userId, rights); failure != "" {
+			refused = append(refused, chatRefusal{ChatId: chatId, Reason: failure})
+		} else {
+			permitted = append(permitted, chatId)
 		}
 
 		time.Sleep(50 * time.Millisecond)
@@ -99,12 +158,47 @@ func isConnected(b *gotgbot.Bot, ctx *ext.Context, userId int64) []int64 {
 
 	// If no valid connections remain, notify the user
 	if len(conn.ChatIds) == 0 {
-		text := "⚠️ No valid connections found. Use <code>/add chat_id</code> to connect."
+		text := tr(ctx, "⚠️ No valid connections found. Use <code>/add chat_id</code> to connect.")
 		_, _ = msg.Reply(b, text, helpers.Shtml())
 		return nil
 	}
 
-	return conn.ChatIds
+	if len(refused) > 0 {
+		lines := strings.Join(refusalLines(i18n.Lang(ctx.EffectiveUser), refused), "\n")
+		if len(permitted) == 0 {
+			_, _ = msg.Reply(b, tr(ctx, "🚫 <b>Missing admin rights in these chats:</b>\n")+lines, helpers.Shtml())
+			return nil
+		}
+		_, _ = msg.Reply(b, tr(ctx, "⚠️ <b>Skipping these chats, admin rights are missing:</b>\n")+lines, helpers.Shtml())
+	}
+	return permitted
+}
+
+// checkChatAdmin checks that a chat exists and that the user is one of its admins.
+// It returns the chat and an empty string, or the reason the check failed.
+func checkChatAdmin(b *gotgbot.Bot, chatId, userId int64) (onlyAdmins.ChatCache, string) {
+	getChat := onlyAdmins.GetChatCache(chatId)
+	if !getChat.Cached {
+		time.Sleep(400 * time.Millisecond)
+		getChat = onlyAdmins.LoadChatCache(b, chatId)
+	}
+
+	if !getChat.Cached {
+		return getChat, i18n.N("Chat not found")
+	}
+
+	cached, _ := onlyAdmins.IsUserAdmin(chatId, userId)
+	if !cached {
+		time.Sleep(100 * time.Millisecond)
+		if admins := onlyAdmins.LoadAdminCache(b, chatId); !admins.Cached {
+			return getChat, i18n.N("Failed to verify admin status")
+		}
+	}
+
+	if _, isUserAdmin := onlyAdmins.IsUserAdmin(chatId, userId); !isUserAdmin {
+		return getChat, i18n.N("You are not an admin")
+	}
+	return getChat, ""
 }
 
 func connect(b *gotgbot.Bot, ctx *ext.Context) error {
@@ -116,7 +210,7 @@ func connect(b *gotgbot.Bot, ctx *ext.Context) error {
 	args := ctx.Args()[1:]
 	reply := msg.ReplyToMessage
 	if len(args) == 0 && (reply == nil || reply.ForwardOrigin == nil) {
-		_, _ = msg.Reply(b, "Please forward a message from a chat so I can get the chat ID.\n\nMake sure that you & i are admin in the chat.", nil)
+		_, _ = msg.Reply(b, tr(ctx, "Pick a channel or group with the buttons below, or forward a message from it.\n\nMake sure that you & i are admin in the chat."), &gotgbot.SendMessageOpts{ReplyMarkup: chatPicker(ctx)})
 		return handlers.NextConversationState(CHATID)
 	}
 
@@ -132,7 +226,7 @@ func connect(b *gotgbot.Bot, ctx *ext.Context) error {
 			chatId := origin.Chat.Id
 			args = append(args, strconv.FormatInt(chatId, 10))
 		} else {
-			_, err := msg.Reply(b, "Please provide at least one chat ID to connect. Use /connect <chat_id> to connect to a chat.", nil)
+			_, err := msg.Reply(b, tr(ctx, "Please provide at least one chat ID to connect. Use /connect <chat_id> to connect to a chat."), nil)
 			return err
 		}
 	}
@@ -140,32 +234,13 @@ func connect(b *gotgbot.Bot, ctx *ext.Context) error {
 	for _, arg := range args {
 		chatId, err := strconv.ParseInt(arg, 10, 64)
 		if err != nil {
-			failedConnections = append(failedConnections, fmt.Sprintf("<code>%s</code> (Invalid ID)", arg))
+			failedConnections = append(failedConnections, tr(ctx, "<code>%s</code> (Invalid ID)", arg))
 			continue
 		}
 
-		getChat := onlyAdmins.GetChatCache(chatId)
-		if !getChat.Cached {
-			time.Sleep(400 * time.Millisecond)
-			getChat = onlyAdmins.LoadChatCache(b, chatId)
-		}
-
-		if !getChat.Cached {
-			failedConnections = append(failedConnections, fmt.Sprintf("<code>%d</code> (Chat not found)", chatId))
-			continue
-		}
-
-		cached, _ := onlyAdmins.IsUserAdmin(chatId, msg.From.Id)
-		if !cached {
-			time.Sleep(100 * time.Millisecond)
-			if admins := onlyAdmins.LoadAdminCache(b, chatId); !admins.Cached {
-				failedConnections = append(failedConnections, fmt.Sprintf("<code>%d</code> (Failed to verify admin status)", chatId))
-				continue
-			}
-		}
-
-		if _, isUserAdmin := onlyAdmins.IsUserAdmin(chatId, msg.From.Id); !isUserAdmin {
-			failedConnections = append(failedConnections, fmt.Sprintf("<code>%d</code> (You are not an admin)", chatId))
+		getChat, failure := checkChatAdmin(b, chatId, msg.From.Id)
+		if failure != "" {
+			failedConnections = append(failedConnections, fmt.Sprintf("<code>%d</code> (%s)", chatId, tr(ctx, failure)))
 			continue
 		}
 
@@ -176,14 +251,14 @@ func connect(b *gotgbot.Bot, ctx *ext.Context) error {
 	// Build reply text
 	var text string
 	if len(successfullyConnected) > 0 {
-		text += "✅ Successfully connected to:\n" + strings.Join(successfullyConnected, "\n") + "\n\n"
+		text += tr(ctx, "✅ Successfully connected to:\n") + strings.Join(successfullyConnected, "\n") + "\n\n"
 	}
 	if len(failedConnections) > 0 {
-		text += "❌ Failed to connect to:\n" + strings.Join(failedConnections, "\n")
+		text += tr(ctx, "❌ Failed to connect to:\n") + strings.Join(failedConnections, "\n")
 	}
 
 	if text == "" {
-		text = "No connections were made. Please check the chat IDs and try again."
+		text = tr(ctx, "No connections were made. Please check the chat IDs and try again.")
 	}
 
 	_, _ = msg.Reply(b, text, helpers.Shtml())
@@ -197,7 +272,7 @@ func disconnect(b *gotgbot.Bot, ctx *ext.Context) error {
 
 	args := ctx.Args()[1:]
 	if len(args) == 0 {
-		_, err := msg.Reply(b, "⚠️ Please provide at least one chat ID to disconnect.", nil)
+		_, err := msg.Reply(b, tr(ctx, "⚠️ Please provide at least one chat ID to disconnect."), nil)
 		return err
 	}
 
@@ -235,15 +310,15 @@ func disconnect(b *gotgbot.Bot, ctx *ext.Context) error {
 
 	var response strings.Builder
 	if len(validChats) > 0 {
-		response.WriteString("✅ Successfully disconnected from the following chat(s):\n")
+		response.WriteString(tr(ctx, "✅ Successfully disconnected from the following chat(s):\n"))
 		response.WriteString(strings.Join(validChats, ", ") + "\n")
 	}
 	if len(invalidChats) > 0 {
-		response.WriteString("⚠️ The following chat ID(s) are invalid or not connected:\n")
+		response.WriteString(tr(ctx, "⚠️ The following chat ID(s) are invalid or not connected:\n"))
 		response.WriteString(strings.Join(invalidChats, ", ") + "\n")
 	}
 	if response.Len() == 0 {
-		response.WriteString("⚠️ No valid chat IDs provided. Please check and try again.")
+		response.WriteString(tr(ctx, "⚠️ No valid chat IDs provided. Please check and try again."))
 	}
 
 	_, err := msg.Reply(b, response.String(), helpers.Shtml())
@@ -264,7 +339,7 @@ func connection(b *gotgbot.Bot, ctx *ext.Context) error {
 		return nil
 	}
 
-	reply, err := msg.Reply(b, "Please wait, fetching connection info...\n\nif you have many chats, This may take a few minutes. Please be patient.", helpers.Shtml())
+	reply, err := msg.Reply(b, tr(ctx, "Please wait, fetching connection info...\n\nif you have many chats, This may take a few minutes. Please be patient."), helpers.Shtml())
 	if err != nil {
 		log.Printf("[connection] Reply Error: %v", err)
 		return err
@@ -273,7 +348,7 @@ func connection(b *gotgbot.Bot, ctx *ext.Context) error {
 	var text string
 	var TotalUsers int64
 	if helpers.Contains(config.FakeDevs, msg.From.Id) {
-		text = "<b>You are currently connected to the following chats:</b>\n\n"
+		text = tr(ctx, "<b>You are currently connected to the following chats:</b>\n\n")
 		for i, chatId := range chatIds {
 			var link string
 			getChat := onlyAdmins.GetChatCache(chatId)
@@ -292,7 +367,7 @@ func connection(b *gotgbot.Bot, ctx *ext.Context) error {
 			}
 
 			TotalUsers += userCount
-			text += fmt.Sprintf(
+			text += tr(ctx,
 				"%d. <b><a href='%s'>%s</a></b>\nChat ID: <code>%d</code>\nMembers: <code>%d</code>\n\n",
 				i+1,                    // Numbering starts from 1
 				link,                   // Chat link
@@ -304,12 +379,13 @@ func connection(b *gotgbot.Bot, ctx *ext.Context) error {
 			time.Sleep(200 * time.Millisecond)
 		}
 
-		text += fmt.Sprintf("<b>Total Users:</b> <code>%d</code>", TotalUsers)
+		text += tr(ctx, "<b>Total Users:</b> <code>%d</code>", TotalUsers)
 		_, _, _ = reply.EditText(b, text, &gotgbot.EditMessageTextOpts{ParseMode: "HTML", LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}})
 		return err
 	}
 
-	text += "<b>You are currently connected to the following chats:</b>\n\n"
+	header := tr(ctx, "<b>You are currently connected to the following chats:</b>\n\n")
+	text += header
 	for i, chatId := range chatIds {
 		getChat := onlyAdmins.GetChatCache(chatId)
 		if !getChat.Cached {
@@ -334,7 +410,7 @@ func connection(b *gotgbot.Bot, ctx *ext.Context) error {
 		}
 
 		// Add numbered chat info with a hyperlink for the title
-		text += fmt.Sprintf(
+		text += tr(ctx,
 			"%d. <b><a href='%s'>%s</a></b>\nChat ID: <code>%d</code>\n\n",
 			i+1,                    // Numbering starts from 1
 			link,                   // Chat link
@@ -343,8 +419,8 @@ func connection(b *gotgbot.Bot, ctx *ext.Context) error {
 		)
 	}
 
-	if text == "<b>You are currently connected to the following chats:</b>\n\n" {
-		text = "⚠️ You are not connected to any chats at the moment."
+	if text == header {
+		text = tr(ctx, "⚠️ You are not connected to any chats at the moment.")
 	}
 
 	_, _, _ = reply.EditText(b, text, &gotgbot.EditMessageTextOpts{ParseMode: "HTML", LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}})
@@ -362,82 +438,80 @@ func noCommands(msg *gotgbot.Message) bool {
 	return false
 }
 
-func askChatID(b *gotgbot.Bot, ctx *ext.Context) error {
-	msg := ctx.EffectiveMessage
-	textChatNotFound := "Chat not found.\nForward a message where i am an admin to get the chat ID."
-	textUserNotAdmin := "You are not an admin in this chat.\nForward a message where you & i are admin to get the chat ID."
-	if msg.ForwardOrigin != nil {
-		origin := msg.ForwardOrigin.MergeMessageOrigin()
-		if origin.Type == "channel" {
-			chatId := origin.Chat.Id
-			getChat := onlyAdmins.GetChatCache(chatId)
-			if !getChat.Cached {
-				time.Sleep(200 * time.Millisecond)
-				getChat = onlyAdmins.LoadChatCache(b, chatId)
-			}
-			if !getChat.Cached {
-				_, _ = msg.Reply(b, textChatNotFound, helpers.Shtml())
-				return handlers.NextConversationState(CHATID)
-			}
-
-			cached, _ := onlyAdmins.IsUserAdmin(chatId, msg.From.Id)
-			if !cached {
-				time.Sleep(100 * time.Millisecond)
-				if admins := onlyAdmins.LoadAdminCache(b, chatId); !admins.Cached {
-					_, _ = msg.Reply(b, textUserNotAdmin, helpers.Shtml())
-					return handlers.NextConversationState(CHATID)
-				}
-			}
+// Ids of the chat picker buttons, Telegram sends them back in the chat_shared message
+const (
+	requestChannel = 1
+	requestGroup   = 2
+)
 
-			if _, isUserAdmin := onlyAdmins.IsUserAdmin(chatId, msg.From.Id); !isUserAdmin {
-				_, _ = msg.Reply(b, textUserNotAdmin, helpers.Shtml())
-				return handlers.NextConversationState(CHATID)
-			}
+// chatPicker is the keyboard of /add to pick a channel or group natively. Telegram only lists chats where the user
+// is an admin and adds the bot with the rights it needs.
+func chatPicker(ctx *ext.Context) gotgbot.ReplyKeyboardMarkup {
+	return gotgbot.ReplyKeyboardMarkup{
+		Keyboard: [][]gotgbot.KeyboardButton{{
+			{Text: tr(ctx, "📢 Pick a channel"), RequestChat: &gotgbot.KeyboardButtonRequestChat{
+				RequestId:               requestChannel,
+				ChatIsChannel:           true,
+				UserAdministratorRights: &gotgbot.ChatAdministratorRights{CanPostMessages: true},
+				BotAdministratorRights:  &gotgbot.ChatAdministratorRights{CanPostMessages: true, CanEditMessages: true, CanDeleteMessages: true},
+			}},
+			{Text: tr(ctx, "👥 Pick a group"), RequestChat: &gotgbot.KeyboardButtonRequestChat{
+				RequestId:               requestGroup,
+				UserAdministratorRights: &gotgbot.ChatAdministratorRights{},
+				BotAdministratorRights:  &gotgbot.ChatAdministratorRights{CanDeleteMessages: true},
+			}},
+		}},
+		ResizeKeyboard:  true,
+		OneTimeKeyboard: true,
+	}
+}
 
-			db.ConnectId(msg.From.Id, chatId)
-			_, _ = msg.Reply(b, fmt.Sprint("You are now connected to ", getChat.ChatInfo.Title, " (", chatId, ")"), helpers.Shtml())
-			return handlers.EndConversation()
+func isChatShared(msg *gotgbot.Message) bool {
+	return msg.ChatShared != nil && (msg.ChatShared.RequestId == requestChannel || msg.ChatShared.RequestId == requestGroup)
+}
 
-		} else if origin.Type == "chat" {
-			chatId := origin.Chat.Id
-			getChat := onlyAdmins.GetChatCache(chatId)
-			if !getChat.Cached {
-				time.Sleep(200 * time.Millisecond)
-				getChat = onlyAdmins.LoadChatCache(b, chatId)
-			}
-			if !getChat.Cached {
-				_, _ = msg.Reply(b, textChatNotFound, helpers.Shtml())
-				return handlers.NextConversationState(CHATID)
-			}
+// chatShared connects a chat picked with the buttons of chatPicker
+func chatShared(b *gotgbot.Bot, ctx *ext.Context) error {
+	return connectChat(b, ctx, ctx.EffectiveMessage.ChatShared.ChatId)
+}
 
-			cached, _ := onlyAdmins.IsUserAdmin(chatId, msg.From.Id)
-			if !cached {
-				time.Sleep(100 * time.Millisecond)
-				if admins := onlyAdmins.LoadAdminCache(b, chatId); !admins.Cached {
-					_, _ = msg.Reply(b, textUserNotAdmin, helpers.Shtml())
-					return handlers.NextConversationState(CHATID)
-				}
-			}
+// connectChat connects a chat of the /add conversation once checkChatAdmin passes, and removes the chat picker
+func connectChat(b *gotgbot.Bot, ctx *ext.Context, chatId int64) error {
+	msg := ctx.EffectiveMessage
+	getChat, failure := checkChatAdmin(b, chatId, msg.From.Id)
+	if !getChat.Cached {
+		_, _ = msg.Reply(b, tr(ctx, "Chat not found.\nForward a message where i am an admin to get the chat ID."), helpers.Shtml())
+		return handlers.NextConversationState(CHATID)
+	}
+	if failure != "" {
+		_, _ = msg.Reply(b, tr(ctx, "%s.\nForward a message where you & i are admin to get the chat ID.", tr(ctx, failure)), helpers.Shtml())
+		return handlers.NextConversationState(CHATID)
+	}
 
-			if _, isUserAdmin := onlyAdmins.IsUserAdmin(chatId, msg.From.Id); !isUserAdmin {
-				_, _ = msg.Reply(b, textUserNotAdmin, helpers.Shtml())
-				return handlers.NextConversationState(CHATID)
-			}
+	db.ConnectId(msg.From.Id, chatId)
+	_, _ = msg.Reply(b, tr(ctx, "You are now connected to %s (%d)", html.EscapeString(getChat.ChatInfo.Title), chatId), &gotgbot.SendMessageOpts{
+		ParseMode:   gotgbot.ParseModeHTML,
+		ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true},
+	})
+	return handlers.EndConversation()
+}
 
-			_, _ = msg.Reply(b, fmt.Sprint("You are now connected to ", getChat.ChatInfo.Title, " (", chatId, ")"), helpers.Shtml())
-			return handlers.EndConversation()
-		} else {
-			_, _ = msg.Reply(b, "Please forward a message from a chat so I can get the chat ID.", nil)
-			return handlers.NextConversationState(CHATID)
+func askChatID(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.ForwardOrigin != nil {
+		origin := msg.ForwardOrigin.MergeMessageOrigin()
+		if origin.Type == "channel" || origin.Type == "chat" {
+			return connectChat(b, ctx, origin.Chat.Id)
 		}
-
 	}
-	_, _ = msg.Reply(b, "Please forward a message from a chat so I can get the chat ID.", nil)
+
+	_, _ = msg.Reply(b, tr(ctx, "Please forward a message from a chat so I can get the chat ID."), nil)
 	return handlers.NextConversationState(CHATID)
 }
 
-// cancel ends the conversation.
-func cancel(_ *gotgbot.Bot, _ *ext.Context) error {
+// cancel ends the conversation and removes the chat picker.
+func cancel(b *gotgbot.Bot, ctx *ext.Context) error {
 	log.Printf("Conversation canceled")
+	_, _ = ctx.EffectiveMessage.Reply(b, tr(ctx, "Cancelled."), &gotgbot.SendMessageOpts{ReplyMarkup: gotgbot.ReplyKeyboardRemove{RemoveKeyboard: true}})
 	return handlers.EndConversation()
 }
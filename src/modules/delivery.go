@@ -0,0 +1,313 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"log"
+	"strings"
+	"time"
+)
+
+// Text limits of Telegram, the same ones preFixes enforces when a post is created
+const (
+	maxTextLength    = 4096
+	maxCaptionLength = 1024
+)
+
+// renderFooter fills in the placeholders of a chat's footer template
+func renderFooter(b *gotgbot.Bot, footer string, chatId int64) string {
+	if !strings.Contains(footer, "{") {
+		return footer
+	}
+
+	getChat := onlyAdmins.GetChatCache(chatId)
+	if !getChat.Cached {
+		getChat = onlyAdmins.LoadChatCache(b, chatId)
+	}
+
+	username := ""
+	if getChat.ChatInfo.Username != "" {
+		username = "@" + getChat.ChatInfo.Username
+	}
+
+	return strings.NewReplacer(
+		"{title}", html.EscapeString(getChat.ChatInfo.Title),
+		"{username}", username,
+		"{chat_id}", fmt.Sprint(chatId),
+	).Replace(footer)
+}
+
+// chatContent returns the text and keyboard of a post for one chat, with the chat's footer and default buttons merged in.
+// The footer is left out if the post would exceed Telegram's text or caption limit with it.
+func chatContent(b *gotgbot.Bot, chatSetting *db.ChatSettings, postId string, msgType int, text string, buttons []db.Button, userSetting *db.UserSettings) (string, gotgbot.InlineKeyboardMarkup) {
+	chatId := chatSetting.ChatId
+
+	if chatSetting.Footer != "" && msgType != db.STICKER && msgType != db.VideoNote {
+		limit := maxCaptionLength
+		if msgType == db.TEXT {
+			limit = maxTextLength
+		}
+
+		footer := renderFooter(b, chatSetting.Footer, chatId)
+		merged := footer
+		if text != "" {
+			merged = text + "\n\n" + footer
+		}
+
+		if length := helpers.TextLength(helpers.PlainText(merged)); length <= limit {
+			text = merged
+		} else {
+			log.Printf("[delivery] Footer of chat %d left out, post %s would be %d characters long", chatId, postId, length)
+		}
+	}
+
+	if len(chatSetting.Buttons) > 0 {
+		buttons = append(append([]db.Button{}, buttons...), chatSetting.Buttons...)
+	}
+
+	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers.BuildKeyboard(helpers.TrackButtons(buttons, postId, chatId, userSetting))}
+	return text, keyboard
+}
+
+// sendPostTo delivers a post to one chat.
+// Photos are watermarked if the chat has a watermark, marks keeps the uploads for the other chats of the delivery.
+func sendPostTo(b *gotgbot.Bot, ctx *ext.Context, userId, chatId int64, postId string, msgType int, text, fileId string, buttons []db.Button, userSetting *db.UserSettings, marks watermarkCache) (*gotgbot.Message, error) {
+	sendFunc, ok := helpers.PostEnumFuncMap[msgType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported post type %d", msgType)
+	}
+
+	chatSetting := db.GetChatSettings(userId, chatId)
+	chatText, keyboard := chatContent(b, chatSetting, postId, msgType, text, buttons, userSetting)
+	if msgType == db.PHOTO && chatSetting.Watermark != nil {
+		photo, err := marks.photo(b, fileId, chatSetting.Watermark)
+		if err != nil {
+			return nil, err
+		}
+
+		message, err := b.SendPhoto(chatId, photo, helpers.PhotoOpts(chatText, &keyboard, chatSetting.Overrides.Apply(userSetting)))
+		marks.store(fileId, chatSetting.Watermark, message)
+		return message, err
+	}
+
+	return sendFunc(b, ctx, chatId, chatText, fileId, &keyboard, userSetting)
+}
+
+// reusableUpload returns the file id of a photo sent by URL, so the other chats of a delivery get the uploaded
+// file instead of fetching the image again. A watermarked copy is not reused, the other chats would get its
+// stamp and the post would record it. It returns fileId when there is nothing to reuse.
+func reusableUpload(userId, chatId int64, msgType int, fileId string, message *gotgbot.Message) string {
+	if msgType != db.PHOTO || !helpers.IsWebURL(fileId) || len(message.Photo) == 0 {
+		return fileId
+	}
+	if db.GetChatSettings(userId, chatId).Watermark != nil {
+		return fileId
+	}
+	return message.Photo[len(message.Photo)-1].FileId
+}
+
+// postInputMedia returns the media used to edit a post into the given type, or nil for types without media
+func postInputMedia(msgType int, file gotgbot.InputFileOrString, caption string, userSetting *db.UserSettings) gotgbot.InputMedia {
+	if caption == "" {
+		caption = "."
+	}
+
+	switch msgType {
+	case db.PHOTO:
+		return gotgbot.InputMediaPhoto{
+			Media:                 file,
+			Caption:               caption,
+			ParseMode:             "HTML",
+			ShowCaptionAboveMedia: userSetting.CaptionAbove,
+			HasSpoiler:            userSetting.Spoiler,
+		}
+	case db.GIF:
+		return gotgbot.InputMediaAnimation{
+			Media:                 file,
+			Caption:               caption,
+			ParseMode:             "HTML",
+			ShowCaptionAboveMedia: userSetting.CaptionAbove,
+			HasSpoiler:            userSetting.Spoiler,
+		}
+	case db.DOCUMENT:
+		return gotgbot.InputMediaDocument{
+			Media:     file,
+			Caption:   caption,
+			ParseMode: "HTML",
+		}
+	case db.AUDIO:
+		return gotgbot.InputMediaAudio{
+			Media:     file,
+			Caption:   caption,
+			ParseMode: "HTML",
+		}
+	case db.VIDEO:
+		return gotgbot.InputMediaVideo{
+			Media:                 file,
+			Caption:               caption,
+			ParseMode:             "HTML",
+			ShowCaptionAboveMedia: userSetting.CaptionAbove,
+			HasSpoiler:            userSetting.Spoiler,
+		}
+	case db.VOICE:
+		return gotgbot.InputMediaAudio{
+			Media:     file,
+			Caption:   caption,
+			ParseMode: "HTML",
+		}
+	default:
+		return nil
+	}
+}
+
+var (
+	errNoEditMedia     = errors.New("the new content has no media to edit the post with")
+	errUnknownPostType = errors.New("unknown data type")
+)
+
+// editPostMessage edits a delivered post message into new content, with the chat's footer, default buttons and watermark.
+// The type the message was sent as decides how it can be edited.
+func editPostMessage(b *gotgbot.Bot, chatSetting *db.ChatSettings, msgId int64, oldType int, postId string, msgType int, text, fileId string, buttons []db.Button, userSetting *db.UserSettings, marks watermarkCache) error {
+	chatId := chatSetting.ChatId
+	userSetting = chatSetting.Overrides.Apply(userSetting)
+	chatText, keyboard := chatContent(b, chatSetting, postId, msgType, text, buttons, userSetting)
+
+	var file gotgbot.InputFileOrString = gotgbot.InputFileByID(fileId)
+	watermark := chatSetting.Watermark
+	if msgType != db.PHOTO || fileId == "" {
+		watermark = nil
+	}
+	if watermark != nil {
+		var err error
+		if file, err = marks.photo(b, fileId, watermark); err != nil {
+			return err
+		}
+	}
+
+	media := postInputMedia(msgType, file, chatText, userSetting)
+	mediaText := chatText
+	if mediaText == "" {
+		mediaText = "."
+	}
+
+	editMedia := func() error {
+		edited, _, err := b.EditMessageMedia(media, &gotgbot.EditMessageMediaOpts{
+			ChatId:      chatId,
+			MessageId:   msgId,
+			ReplyMarkup: keyboard,
+		})
+		if err == nil && watermark != nil {
+			marks.store(fileId, watermark, edited)
+		}
+		return err
+	}
+
+	switch oldType {
+	case db.TEXT:
+		if fileId != "" {
+			if media == nil {
+				return errNoEditMedia
+			}
+			return editMedia()
+		}
+		_, _, err := b.EditMessageText(mediaText, &gotgbot.EditMessageTextOpts{
+			ChatId:             chatId,
+			MessageId:          msgId,
+			ParseMode:          "HTML",
+			ReplyMarkup:        keyboard,
+			LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: userSetting.WebPreview},
+		})
+		return err
+	case db.PHOTO:
+		if fileId != "" {
+			return editMedia()
+		}
+		_, _, err := b.EditMessageCaption(&gotgbot.EditMessageCaptionOpts{
+			ChatId:      chatId,
+			MessageId:   msgId,
+			Caption:     mediaText,
+			ParseMode:   "HTML",
+			ReplyMarkup: keyboard,
+		})
+		return err
+	case db.STICKER:
+		_, _, err := b.EditMessageReplyMarkup(&gotgbot.EditMessageReplyMarkupOpts{
+			ChatId:      chatId,
+			MessageId:   msgId,
+			ReplyMarkup: keyboard,
+		})
+		return err
+	case db.AUDIO, db.VIDEO, db.VOICE, db.GIF, db.DOCUMENT:
+		return editMedia()
+	default:
+		return errUnknownPostType
+	}
+}
+
+// postDelivery is the result of delivering a post to one chat
+type postDelivery struct {
+	ChatId  int64
+	Message *gotgbot.Message // nil if the post could not be sent
+	Err     error
+	PinErr  error
+}
+
+// deliverPost sends the content of a post to chats as postId with the post's options, recording each
+// message and queueing the post's comment and pin. Sends pause for a minute after every 23 chats.
+// Photos given as URL are uploaded once, the other chats get the uploaded file unless it was watermarked.
+func deliverPost(b *gotgbot.Bot, ctx *ext.Context, userId int64, postId string, post *db.Post, chatIds []int64) []postDelivery {
+	userSetting := db.GetUserSettings(userId)
+	userSetting.Options = post.Options
+	marks := make(watermarkCache)
+	fileId := post.FileID
+	deliveries := make([]postDelivery, 0, len(chatIds))
+	sent := 0
+
+	for i, chatId := range chatIds {
+		if i > 0 && i%23 == 0 {
+			log.Printf("[deliverPost] Reached batch limit, pausing for 1 minute...")
+			time.Sleep(1 * time.Minute)
+		}
+
+		message, err := sendPostTo(b, ctx, userId, chatId, postId, post.MsgType, post.FilterReply, fileId, post.Buttons, userSetting, marks)
+		delivery := postDelivery{ChatId: chatId, Message: message, Err: err}
+		if err != nil {
+			log.Printf("Failed to send post to chat %d: %v", chatId, err)
+			emitFailed(userId, postId, chatId, err)
+			delivery.Message = nil
+			deliveries = append(deliveries, delivery)
+			continue
+		}
+
+		fileId = reusableUpload(userId, chatId, post.MsgType, fileId, message)
+
+		_, _ = db.AddPost(postId, userId, chatId, message.MessageId, post.MsgType, fileId, post.Buttons, post.FilterReply)
+		emitSent(userId, postId, message)
+		queueComment(b, chatId, message.MessageId, postId, post.Comment)
+		if post.Pin != nil {
+			delivery.PinErr = pinMessage(b, chatId, message.MessageId, post.Pin)
+		}
+		deliveries = append(deliveries, delivery)
+		sent++
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if sent > 0 && postId != post.PostId {
+		if post.Comment != "" {
+			_ = db.SetPostComment(postId, post.Comment)
+		}
+		if post.Pin != nil {
+			_ = db.SetPostPin(postId, post.Pin)
+		}
+		if post.Options != nil {
+			_ = db.SetPostOptions(postId, post.Options)
+		}
+	}
+	return deliveries
+}
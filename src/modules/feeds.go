@@ -0,0 +1,355 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/config"
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"AshokShau/channelManager/src/modules/utils/rss"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// feedInterval is how often the feeds are polled
+	feedInterval = 10 * time.Minute
+	// maxFeedItems is the number of new items posted per feed and poll, older new items are skipped
+	maxFeedItems = 5
+)
+
+var feedTemplateRegex = regexp.MustCompile(`(?s)^\S+\s+template\s+(\S+)\s*(.*)$`)
+
+// deliverFeedItem posts a feed item to the given chats, as a photo post if the item has an image and its text fits in a caption.
+// The summary also lists the chats that were refused for missing admin rights.
+func deliverFeedItem(b *gotgbot.Bot, feed *db.Feed, tmpl *template.Template, item rss.Item, chatIds []int64, refused []chatRefusal) error {
+	postText, err := rss.Render(tmpl, feed.Title, item)
+	if err != nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	length := helpers.TextLength(helpers.PlainText(postText))
+	if length > maxTextLength {
+		return fmt.Errorf("item %s is %d characters long after rendering", item.GUID, length)
+	}
+
+	dataType, fileId := db.TEXT, ""
+	if helpers.IsWebURL(item.Image) && length <= maxCaptionLength {
+		dataType, fileId = db.PHOTO, item.Image
+	}
+
+	var buttons []db.Button
+	if link, err := helpers.NormalizeButtonUrl(item.Link); err == nil {
+		buttons = append(buttons, db.Button{Name: "Read more", Type: db.ButtonUrl, Url: link})
+	}
+
+	intro := trUser(feed.UserId, "🚫 <b>%s</b> from <b>%s</b> was not posted, it breaks your content policies:\n\n", html.EscapeString(item.Title), html.EscapeString(feed.Title))
+	if policyBlocked(b, feed.UserId, chatIds, postText, buttons, false, intro) {
+		return errors.New("the item breaks the content policies")
+	}
+
+	postId := helpers.GenerateUniqueString()
+	userSetting := db.GetUserSettings(feed.UserId)
+	marks := make(watermarkCache)
+	var failedChats []string
+	sent := 0
+	for _, chatId := range chatIds {
+		message, err := sendPostTo(b, nil, feed.UserId, chatId, postId, dataType, postText, fileId, buttons, userSetting, marks)
+		if err != nil && dataType == db.PHOTO && helpers.IsWebURL(fileId) {
+			// Telegram could not fetch the image, post the item as text instead
+			log.Printf("[feed] Image %s of feed %s failed: %v", fileId, feed.FeedId, err)
+			dataType, fileId = db.TEXT, ""
+			message, err = sendPostTo(b, nil, feed.UserId, chatId, postId, dataType, postText, fileId, buttons, userSetting, marks)
+		}
+		if err != nil {
+			log.Printf("[feed] Failed to send item of feed %s to chat %d: %v", feed.FeedId, chatId, err)
+			emitFailed(feed.UserId, postId, chatId, err)
+			failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
+			continue
+		}
+
+		// The other chats get the uploaded photo instead of fetching the image again
+		fileId = reusableUpload(feed.UserId, chatId, dataType, fileId, message)
+
+		_, _ = db.AddPost(postId, feed.UserId, chatId, message.MessageId, dataType, fileId, buttons, postText)
+		emitSent(feed.UserId, postId, message)
+		sent++
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if sent == 0 {
+		return errors.New("the item could not be sent to any chat")
+	}
+
+	text := trUser(feed.UserId, "📰 Posted <b>%s</b> from <b>%s</b> to %d chats.\n", html.EscapeString(item.Title), html.EscapeString(feed.Title), sent)
+	if len(failedChats) > 0 {
+		text += trUser(feed.UserId, "❌ Failed to send to: %s\n", strings.Join(failedChats, ", "))
+	}
+	lang := i18n.UserLang(feed.UserId)
+	if len(refused) > 0 {
+		text += trUser(feed.UserId, "🚫 Skipped, admin rights are missing: %s\n", strings.Join(refusalLines(lang, refused), ", "))
+	}
+	text += trUser(feed.UserId, "\n<b>PostId:</b> <code>%s</code>", postId)
+	_, _ = b.SendMessage(feed.UserId, text, &gotgbot.SendMessageOpts{
+		ParseMode:           gotgbot.ParseModeHTML,
+		ReplyMarkup:         helpers.PostButton(lang, postId),
+		DisableNotification: true,
+	})
+	return nil
+}
+
+// pollFeed posts the items of a feed that were not seen before and returns how many were posted
+func pollFeed(b *gotgbot.Bot, feed *db.Feed) (int, error) {
+	parsed, err := fetchFeed(feed.Url)
+	if err != nil {
+		_ = db.MarkFeedItems(feed.FeedId, nil, err.Error())
+		return 0, err
+	}
+
+	seen := make(map[string]bool, len(feed.Seen))
+	for _, guid := range feed.Seen {
+		seen[guid] = true
+	}
+
+	var newItems []rss.Item
+	var guids []string
+	for _, item := range parsed.Items {
+		if item.GUID == "" || seen[item.GUID] {
+			continue
+		}
+		seen[item.GUID] = true
+		newItems = append(newItems, item)
+		guids = append(guids, item.GUID)
+	}
+	if len(newItems) == 0 {
+		return 0, db.MarkFeedItems(feed.FeedId, nil, "")
+	}
+
+	chatIds, err := groupChats(feed.UserId, feed.Group)
+	var refused []chatRefusal
+	if err == nil {
+		chatIds, refused = permittedChats(b, feed.UserId, chatIds, onlyAdmins.CanPostMessages)
+	}
+	if err == nil && len(chatIds) == 0 {
+		err = errors.New("no connected chats to post to")
+		if len(refused) > 0 {
+			err = errors.New("admin rights are missing in all chats to post to")
+		}
+	}
+	if err != nil {
+		// Keep the items unseen so they are posted once the chats are back
+		_ = db.MarkFeedItems(feed.FeedId, nil, err.Error())
+		return 0, err
+	}
+
+	tmpl, err := rss.ParseTemplate(feed.Template)
+	if err != nil {
+		_ = db.MarkFeedItems(feed.FeedId, nil, err.Error())
+		return 0, err
+	}
+
+	// Feeds list the newest items first, post the latest few in the order they were published
+	newItems = newItems[:min(len(newItems), maxFeedItems)]
+	posted := 0
+	var pollErr error
+	for i := len(newItems) - 1; i >= 0; i-- {
+		if err := deliverFeedItem(b, feed, tmpl, newItems[i], chatIds, refused); err != nil {
+			log.Printf("[feed] Feed %s: %v", feed.FeedId, err)
+			pollErr = err
+			continue
+		}
+		posted++
+	}
+
+	errText := ""
+	if pollErr != nil {
+		errText = pollErr.Error()
+	}
+	_ = db.MarkFeedItems(feed.FeedId, guids, errText)
+	return posted, pollErr
+}
+
+func fetchFeed(feedUrl string) (*rss.Feed, error) {
+	data, err := helpers.FetchFeed(feedUrl)
+	if err != nil {
+		return nil, err
+	}
+	return rss.Parse(data)
+}
+
+// runFeeds polls all feed subscriptions
+func runFeeds(b *gotgbot.Bot) {
+	feeds, err := db.AllFeeds()
+	if err != nil {
+		log.Printf("[feed] AllFeeds: %v", err)
+		return
+	}
+
+	for i := range feeds {
+		if _, err = pollFeed(b, &feeds[i]); err != nil {
+			log.Printf("[feed] Failed to poll feed %s: %v", feeds[i].FeedId, err)
+		}
+	}
+}
+
+// userFeed retrieves a feed of the user, replying if it doesn't exist
+func userFeed(b *gotgbot.Bot, msg *gotgbot.Message, feedId string) *db.Feed {
+	feed, err := db.GetFeed(feedId)
+	if err != nil || feed == nil || feed.UserId != msg.From.Id {
+		_, _ = msg.Reply(b, i18n.T(i18n.Lang(msg.From), "Feed not found."), helpers.Shtml())
+		return nil
+	}
+	return feed
+}
+
+func setFeed(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 2 {
+		feeds, _ := db.ListFeeds(msg.From.Id)
+		var text strings.Builder
+		text.WriteString(tr(ctx, "New articles of RSS and Atom feeds are posted to your chats every %s.\n"+
+			"Usage: <code>!feed add url [group]</code>\n"+
+			"Without a group, articles are posted to all connected chats.\n\n"+
+			"<code>!feed template feed_id text</code> - Set how articles are rendered\n"+
+			"<code>!feed check feed_id</code> - Check a feed for new articles now\n"+
+			"<code>!feed remove feed_id</code> - Stop posting a feed\n", feedInterval))
+
+		if len(feeds) > 0 {
+			text.WriteString(tr(ctx, "\n<b>Your feeds:</b>\n"))
+			for _, feed := range feeds {
+				group := tr(ctx, "all chats")
+				if feed.Group != "" {
+					group = tr(ctx, "group %s", feed.Group)
+				}
+				text.WriteString(fmt.Sprintf("<code>%s</code> - %s → %s\n", feed.FeedId, html.EscapeString(feed.Title), group))
+				if feed.Error != "" {
+					text.WriteString(fmt.Sprintf("⚠️ %s\n", html.EscapeString(feed.Error)))
+				}
+			}
+		}
+
+		_, err := msg.Reply(b, text.String(), helpers.Shtml())
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		feedUrl := args[1]
+		// Local files are for testing feeds, only the owner may read files of the server
+		if !helpers.IsWebURL(feedUrl) && !(strings.HasPrefix(feedUrl, "file://") && msg.From.Id == config.OwnerId) {
+			_, err := msg.Reply(b, tr(ctx, "Please provide an http or https URL of the feed."), helpers.Shtml())
+			return err
+		}
+
+		group := ""
+		if len(args) > 2 {
+			group = args[2]
+			if _, err := groupChats(msg.From.Id, group); err != nil {
+				_, err = msg.Reply(b, tr(ctx, "Group <code>%s</code> not found, create it with <code>!group</code>.", html.EscapeString(group)), helpers.Shtml())
+				return err
+			}
+		}
+
+		parsed, err := fetchFeed(feedUrl)
+		if err != nil {
+			_, err = msg.Reply(b, tr(ctx, "Could not load the feed: %s", html.EscapeString(err.Error())), helpers.Shtml())
+			return err
+		}
+
+		// Only articles published from now on are posted
+		feed := &db.Feed{FeedId: helpers.GenerateUniqueString(), UserId: msg.From.Id, Url: feedUrl, Title: parsed.Title, Group: group}
+		if feed.Title == "" {
+			feed.Title = feedUrl
+		}
+		for _, item := range parsed.Items {
+			feed.Seen = append(feed.Seen, item.GUID)
+		}
+
+		if err = db.AddFeed(feed); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error saving the feed."), helpers.Shtml())
+			return err
+		}
+
+		_, err = msg.Reply(b, tr(ctx, "Feed <b>%s</b> added, new articles will be posted.\n\n<b>FeedId:</b> <code>%s</code>", html.EscapeString(feed.Title), feed.FeedId), helpers.Shtml())
+		return err
+	case "remove", "off":
+		feed := userFeed(b, msg, args[1])
+		if feed == nil {
+			return nil
+		}
+
+		if err := db.RemoveFeed(feed.FeedId); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the feed."), helpers.Shtml())
+			return err
+		}
+		_, err := msg.Reply(b, tr(ctx, "Feed <b>%s</b> removed.", html.EscapeString(feed.Title)), helpers.Shtml())
+		return err
+	case "template":
+		feed := userFeed(b, msg, args[1])
+		if feed == nil {
+			return nil
+		}
+
+		text := ""
+		if match := feedTemplateRegex.FindStringSubmatch(msg.Text); match != nil {
+			text = strings.TrimSpace(match[2])
+		}
+		if text == "" {
+			current := feed.Template
+			if current == "" {
+				current = rss.DefaultTemplate
+			}
+			_, err := msg.Reply(b, tr(ctx, "<b>Current template:</b>\n<pre>%s</pre>\n\n"+
+				"Placeholders: <code>{{.Title}}</code>, <code>{{.Summary}}</code>, <code>{{.Link}}</code>, <code>{{.Feed}}</code>\n"+
+				"HTML formatting is allowed, use <code>default</code> to restore the default template.", html.EscapeString(current)), helpers.Shtml())
+			return err
+		}
+		if text == "default" {
+			text = ""
+		}
+
+		tmpl, err := rss.ParseTemplate(text)
+		if err == nil {
+			_, err = rss.Render(tmpl, feed.Title, rss.Item{Title: "Title", Link: "https://example.com", Summary: "Summary"})
+		}
+		if err != nil {
+			_, err = msg.Reply(b, tr(ctx, "Invalid template: %s", html.EscapeString(err.Error())), helpers.Shtml())
+			return err
+		}
+
+		if err = db.SetFeedTemplate(feed.FeedId, text); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error saving the template."), helpers.Shtml())
+			return err
+		}
+		_, err = msg.Reply(b, tr(ctx, "Template saved."), helpers.Shtml())
+		return err
+	case "check":
+		feed := userFeed(b, msg, args[1])
+		if feed == nil {
+			return nil
+		}
+
+		posted, err := pollFeed(b, feed)
+		text := tr(ctx, "Posted %d new articles of <b>%s</b>.", posted, html.EscapeString(feed.Title))
+		if err != nil {
+			text += "\n⚠️ " + html.EscapeString(err.Error())
+		}
+		_, err = msg.Reply(b, text, helpers.Shtml())
+		return err
+	default:
+		_, err := msg.Reply(b, tr(ctx, "Please use <code>!feed add</code>, <code>template</code>, <code>check</code> or <code>remove</code>."), helpers.Shtml())
+		return err
+	}
+}
@@ -0,0 +1,81 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"strconv"
+	"strings"
+)
+
+func setFooter(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		text := tr(ctx, "Please provide a chat ID and the footer to add to posts in that chat.\n"+
+			"Usage: <code>!footer chat_id text</code>\n\n"+
+			"The footer can contain buttons (url, share and copy), which are added below the post buttons.\n"+
+			"Placeholders: <code>{title}</code>, <code>{username}</code>, <code>{chat_id}</code>\n"+
+			"Use <code>!footer chat_id off</code> to remove it.")
+		_, err := msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	chatId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || !helpers.Contains(db.Connection(msg.From.Id).ChatIds, chatId) {
+		_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is not one of your connected chats.", args[0]), helpers.Shtml())
+		return err
+	}
+
+	chatSetting := db.GetChatSettings(msg.From.Id, chatId)
+	if len(args) == 1 {
+		if chatSetting.Footer == "" && len(chatSetting.Buttons) == 0 {
+			_, err = msg.Reply(b, tr(ctx, "This chat has no footer."), helpers.Shtml())
+			return err
+		}
+
+		keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers.BuildKeyboard(chatSetting.Buttons)}
+		text := chatSetting.Footer
+		if text == "" {
+			text = tr(ctx, "This chat only has default buttons.")
+		}
+		_, err = msg.Reply(b, text, &gotgbot.SendMessageOpts{ParseMode: "HTML", ReplyMarkup: keyboard, LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: true}})
+		return err
+	}
+
+	if args[1] == "off" {
+		if err = db.SetChatFooter(msg.From.Id, chatId, "", nil); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the footer."), helpers.Shtml())
+			return err
+		}
+		_, err = msg.Reply(b, tr(ctx, "Footer removed."), helpers.Shtml())
+		return err
+	}
+
+	raw := msg.OriginalMDV2()
+	raw = raw[strings.Index(raw, args[0])+len(args[0]):]
+	footer, buttons, errorMsg := helpers.ParseFooter(i18n.Lang(msg.From), raw)
+	if errorMsg != "" {
+		_, err = msg.Reply(b, errorMsg, helpers.Shtml())
+		return err
+	}
+
+	if length := helpers.TextLength(helpers.PlainText(footer)); length > maxCaptionLength {
+		_, err = msg.Reply(b, tr(ctx, "Your footer is %d characters long. The maximum footer length is %d, so it fits under captions.", length, maxCaptionLength), helpers.Shtml())
+		return err
+	}
+
+	if err = db.SetChatFooter(msg.From.Id, chatId, footer, buttons); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the footer."), helpers.Shtml())
+		return err
+	}
+
+	_, err = msg.Reply(b, tr(ctx, "Footer saved for <code>%d</code>.\nIt is left out of posts that would exceed Telegram's length limits with it.", chatId), helpers.Shtml())
+	return err
+}
@@ -0,0 +1,101 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var groupNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// groupChats returns the chats of a user's group that are still connected, all connected chats for an empty group
+func groupChats(userId int64, group string) ([]int64, error) {
+	connected := db.Connection(userId).ChatIds
+	if group == "" {
+		return connected, nil
+	}
+
+	chatGroup, err := db.GetChatGroup(userId, group)
+	if err != nil {
+		return nil, err
+	}
+	if chatGroup == nil {
+		return nil, fmt.Errorf("group %s does not exist", group)
+	}
+
+	var chatIds []int64
+	for _, chatId := range chatGroup.ChatIds {
+		if helpers.Contains(connected, chatId) {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+	return chatIds, nil
+}
+
+func setGroup(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 2 {
+		groups, _ := db.ListChatGroups(msg.From.Id)
+		var text strings.Builder
+		text.WriteString(tr(ctx, "Groups name a set of your connected chats for feeds and other automated posts.\n"+
+			"Usage: <code>!group name chat_id chat_id2 ..</code>\n"+
+			"Use <code>!group name off</code> to remove a group.\n"))
+
+		if len(groups) > 0 {
+			text.WriteString(tr(ctx, "\n<b>Your groups:</b>\n"))
+			for _, group := range groups {
+				text.WriteString(fmt.Sprintf("<code>%s</code> → %s\n", group.Name, strings.Trim(fmt.Sprint(group.ChatIds), "[]")))
+			}
+		}
+
+		_, err := msg.Reply(b, text.String(), helpers.Shtml())
+		return err
+	}
+
+	name := args[0]
+	if !groupNameRegex.MatchString(name) {
+		_, err := msg.Reply(b, tr(ctx, "Group names may only contain letters, digits, <code>_</code> and <code>-</code>."), helpers.Shtml())
+		return err
+	}
+
+	if args[1] == "off" {
+		if err := db.RemoveChatGroup(msg.From.Id, name); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the group."), helpers.Shtml())
+			return err
+		}
+		_, err := msg.Reply(b, tr(ctx, "Group <code>%s</code> removed.", name), helpers.Shtml())
+		return err
+	}
+
+	connected := db.Connection(msg.From.Id).ChatIds
+	var chatIds []int64
+	for _, arg := range args[1:] {
+		chatId, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || !helpers.Contains(connected, chatId) {
+			_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is not one of your connected chats.", html.EscapeString(arg)), helpers.Shtml())
+			return err
+		}
+		if !helpers.Contains(chatIds, chatId) {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+
+	if err := db.SetChatGroup(msg.From.Id, name, chatIds); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the group."), helpers.Shtml())
+		return err
+	}
+
+	_, err := msg.Reply(b, tr(ctx, "Group <code>%s</code> saved with %d chats.", name, len(chatIds)), helpers.Shtml())
+	return err
+}
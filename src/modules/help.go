@@ -1,9 +1,13 @@
 package modules
 
 import (
+	"AshokShau/channelManager/src"
 	"AshokShau/channelManager/src/config"
 	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
 	"fmt"
+	"html"
+	"strings"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -13,18 +17,18 @@ func start(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
 	go db.GetUserSettings(msg.From.Id)
 
-	helpText := fmt.Sprintf("Hello, <b>%s</b>! <blockquote>I'm an Advanced channel manager BoT</blockquote>\n\n<blockquote>👉 Features Like Schedule Deleting,Multiple Channels,Repost,Edit Post and More...</blockquote>\n\n<b>Share and Support Us</b>\n\n<b>Use /help for more information.</b>", msg.From.FirstName)
+	helpText := tr(ctx, "Hello, <b>%s</b>! <blockquote>I'm an Advanced channel manager BoT</blockquote>\n\n<blockquote>👉 Features Like Schedule Deleting,Multiple Channels,Repost,Edit Post and More...</blockquote>\n\n<b>Share and Support Us</b>\n\n<b>Use /help for more information.</b>", msg.From.FirstName)
 	button := &gotgbot.InlineKeyboardMarkup{
 		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
 			{
 				{
-					Text: "Add me to your channel",
+					Text: tr(ctx, "Add me to your channel"),
 					Url:  fmt.Sprint("https://t.me/", b.Username, "?startchannel=new"),
 				},
 			},
 			{
 				{
-					Text: "SUPPORT CHANNEL 🙋‍♀️",
+					Text: tr(ctx, "SUPPORT CHANNEL 🙋‍♀️"),
 					Url:  config.SupportChat,
 				},
 			},
@@ -40,66 +44,82 @@ func help(b *gotgbot.Bot, ctx *ext.Context) error {
 	if msg.Chat.Type != "private" {
 		return nil
 	}
-	helpText := `HELLO! I'M AN ADVANCED CHANNEL MANAGER BOT
 
-<b>Connection commands:</b>
-<code>/add </code> - Connect to a channel or group
+	if args := ctx.Args()[1:]; len(args) > 0 {
+		cmd, ok := src.FindCommand(args[0])
+		if !ok {
+			_, err := msg.Reply(b, tr(ctx, "Unknown command <code>%s</code>, see /help.", html.EscapeString(args[0])), helpers.Shtml())
+			return err
+		}
 
-<code>/add chat_id chat_id2 ..</code>: connect to multiple channels
-<code>/add</code>: reply to a <b>Forwarded</b> message to connect to a channel or group
-<code>/add</code>: bot ask to <b>Forward</b> me the Message of that Channel that you want to Add
-
-<code>/remove</code>:  Disconnect from a channel or multiple channels
-<code>!channels</code> - List all connected channels
-
-<b>Post commands:</b>
-<code>!del channel_id msg_id</code> - Delete a message from a channel
-<code>!create</code> - Create a post or get postId
-<code>!get PostId</code> - Share a post in current chat || Get post preview
-<code>!send Reply</code> - Send a post to all connected channels
-<code>!repost PostId</code> - Re-post a post from all connected channels (del old post and send new post)
-<code>!edit PostId</code> - Edit a post from all connected chats
-
-<b>User Settings:</b>
-<code>!forward</code> - Toggle forward tag
-<code>!silent</code> - Toggle no notification
-<code>!protect</code> - Toggle protect
-<code>!spoiler</code> - Toggle spoiler
-<code>!preview</code> - Toggle web preview
-<code>!captionabove</code> - Toggle caption above
-<code>!reset</code> - Reset all user settings (Set to default value: off)
-
-<b>Inline Commands:</b>
-<code>@%s PostId</code> - Share a post in current chat (Via Inline)
-
-<b>Add Buttons:</b>
-Simple buttons:
-- The following syntax will create a button called "Google", which will open google.com
--> [Google](buttonurl://google.com)
-
-
-Buttons on the same line:
-- This example creates two buttons ("Google" and "Bing"), which will appear on the same line. This is achieved with the :same tag on the second button.
--> [Google](buttonurl://google.com) [Bing](buttonurl://bing.com:same)
-`
+		_, err := msg.Reply(b, commandHelp(ctx, cmd), helpers.Shtml())
+		return err
+	}
 
 	button := &gotgbot.InlineKeyboardMarkup{
 		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
 			{
 				{
-					Text: "Add me to your channel",
+					Text: tr(ctx, "Add me to your channel"),
 					Url:  fmt.Sprint("https://t.me/", b.Username, "?startchannel=new"),
 				},
 			},
 			{
 				{
-					Text: "SUPPORT CHANNEL 🙋‍♀️",
+					Text: tr(ctx, "SUPPORT CHANNEL 🙋‍♀️"),
 					Url:  config.SupportChat,
 				},
 			},
 		},
 	}
 
-	_, _ = msg.Reply(b, fmt.Sprintf(helpText, b.Username), &gotgbot.SendMessageOpts{ParseMode: "HTML", ReplyMarkup: button})
+	// The guide to buttons and options is a message of its own, with the commands both would be too long for Telegram
+	if _, err := msg.Reply(b, commandList(ctx, msg.From.Id == config.OwnerId), helpers.Shtml()); err != nil {
+		return err
+	}
+	_, _ = b.SendMessage(msg.Chat.Id, tr(ctx, "help_posts", b.Username), &gotgbot.SendMessageOpts{ParseMode: "HTML", ReplyMarkup: button})
 	return nil
 }
+
+// commandList lists the registered commands by section, owner commands only for the owner
+func commandList(ctx *ext.Context, owner bool) string {
+	var text strings.Builder
+	text.WriteString(tr(ctx, "HELLO! I'M AN ADVANCED CHANNEL MANAGER BOT\n\nCommands start with / or !, use <code>/help command</code> to see the aliases of a command.\n"))
+	for _, section := range src.Sections {
+		if section == src.SectionOwner && !owner {
+			continue
+		}
+
+		var lines []string
+		for _, cmd := range src.Commands {
+			if cmd.Section == section {
+				lines = append(lines, fmt.Sprintf("<code>%s</code> - %s", commandUsage(cmd), tr(ctx, cmd.Description)))
+			}
+		}
+		if len(lines) > 0 {
+			text.WriteString(fmt.Sprintf("\n<b>%s:</b>\n%s\n", tr(ctx, section), strings.Join(lines, "\n")))
+		}
+	}
+	return text.String()
+}
+
+// commandHelp explains one command with its usage and aliases
+func commandHelp(ctx *ext.Context, cmd src.Command) string {
+	text := tr(ctx, "<b>/%s</b> - %s\n\n<b>Usage:</b> <code>%s</code>", cmd.Names[0], tr(ctx, cmd.Description), commandUsage(cmd))
+	if len(cmd.Names) > 1 {
+		aliases := make([]string, 0, len(cmd.Names)-1)
+		for _, alias := range cmd.Names[1:] {
+			aliases = append(aliases, "<code>/"+alias+"</code>")
+		}
+		text += tr(ctx, "\n<b>Aliases:</b> %s", strings.Join(aliases, ", "))
+	}
+	if cmd.Permission == src.PermOwner {
+		text += tr(ctx, "\n\nOnly the owner can use this command.")
+	}
+	return text
+}
+
+// commandUsage is the main name of a command followed by its arguments
+func commandUsage(cmd src.Command) string {
+	return strings.TrimSpace("/" + cmd.Names[0] + " " + cmd.Usage)
+}
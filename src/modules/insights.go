@@ -0,0 +1,161 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"html"
+	"log"
+	"sort"
+	"strings"
+)
+
+// reactionCountHandler handles message_reaction_count updates, which gotgbot has no handler for.
+type reactionCountHandler struct {
+	response handlers.Response
+}
+
+func (h reactionCountHandler) CheckUpdate(_ *gotgbot.Bot, ctx *ext.Context) bool {
+	return ctx.Update.MessageReactionCount != nil
+}
+
+func (h reactionCountHandler) HandleUpdate(b *gotgbot.Bot, ctx *ext.Context) error {
+	return h.response(b, ctx)
+}
+
+func (h reactionCountHandler) Name() string {
+	return fmt.Sprintf("reaction_count_%p", h.response)
+}
+
+// reactionKey returns the key under which a reaction type is counted
+func reactionKey(reaction gotgbot.ReactionType) string {
+	merged := reaction.MergeReactionType()
+	switch merged.Type {
+	case "emoji":
+		return merged.Emoji
+	case "custom_emoji":
+		return "custom:" + merged.CustomEmojiId
+	case "paid":
+		return "⭐"
+	default:
+		return merged.Type
+	}
+}
+
+// reactionCountUpdate stores the reaction totals of a message if it belongs to a post
+func reactionCountUpdate(_ *gotgbot.Bot, ctx *ext.Context) error {
+	update := ctx.Update.MessageReactionCount
+	post, err := db.GetPostByMessage(update.Chat.Id, update.MessageId)
+	if err != nil || post == nil {
+		return err
+	}
+
+	reactions := make(map[string]int64, len(update.Reactions))
+	for _, reaction := range update.Reactions {
+		reactions[reactionKey(reaction.Type)] += reaction.TotalCount
+	}
+
+	if err = db.SetMessageReactions(post.PostId, update.Chat.Id, update.MessageId, reactions, update.Date); err != nil {
+		log.Printf("[insights] Failed to store reactions for chat %d: %v", update.Chat.Id, err)
+	}
+	return nil
+}
+
+// formatReactions renders reaction totals sorted by count, e.g. "👍 12 · 🔥 4"
+func formatReactions(lang string, reactions map[string]int64) string {
+	if len(reactions) == 0 {
+		return i18n.T(lang, "no reactions")
+	}
+
+	keys := make([]string, 0, len(reactions))
+	for key := range reactions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if reactions[keys[i]] == reactions[keys[j]] {
+			return keys[i] < keys[j]
+		}
+		return reactions[keys[i]] > reactions[keys[j]]
+	})
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s %d", html.EscapeString(key), reactions[key])
+	}
+	return strings.Join(parts, " · ")
+}
+
+func insights(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	lang := i18n.Lang(ctx.EffectiveUser)
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		_, err := msg.Reply(b, tr(ctx, "Please provide a PostId to get its insights.\nUsage: <code>!insights PostId</code>"), helpers.Shtml())
+		return err
+	}
+
+	post, err := db.GetPost(args[0])
+	if err != nil || post == nil || post.UserId != msg.From.Id {
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or error retrieving post."), helpers.Shtml())
+		return err
+	}
+
+	postReactions, err := db.GetPostReactions(post.Chats)
+	if err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error retrieving post insights."), helpers.Shtml())
+		return err
+	}
+
+	byChat := make(map[int64]map[string]int64)
+	total := make(map[string]int64)
+	for _, message := range postReactions {
+		for key, count := range message.Reactions {
+			if byChat[message.ChatId] == nil {
+				byChat[message.ChatId] = make(map[string]int64)
+			}
+			byChat[message.ChatId][key] += count
+			total[key] += count
+		}
+	}
+
+	var text strings.Builder
+	text.WriteString(tr(ctx, "<b>📈 Insights for</b> <code>%s</code>\n\n", post.PostId))
+	text.WriteString(tr(ctx, "<b>All chats:</b> %s\n\n", formatReactions(lang, total)))
+
+	seen := make(map[int64]bool)
+	for _, chat := range post.Chats {
+		if seen[chat.ChatId] || chat.Comment || chat.ChatId == b.Id {
+			continue
+		}
+		seen[chat.ChatId] = true
+
+		title := fmt.Sprint(chat.ChatId)
+		if getChat := onlyAdmins.GetChatCache(chat.ChatId); getChat.Cached {
+			title = getChat.ChatInfo.Title
+		}
+
+		text.WriteString(fmt.Sprintf("<b>%s</b> (<code>%d</code>)\n", html.EscapeString(title), chat.ChatId))
+		text.WriteString(tr(ctx, "Post: %s\n", formatReactions(lang, byChat[chat.ChatId])))
+
+		channelTotal, messages, err := db.GetChatReactions(chat.ChatId)
+		if err != nil {
+			log.Printf("[insights] GetChatReactions: %v - Chat: %d", err, chat.ChatId)
+			text.WriteString("\n")
+			continue
+		}
+		text.WriteString(tr(ctx, "Channel (%d posts): %s\n\n", messages, formatReactions(lang, channelTotal)))
+	}
+
+	text.WriteString(tr(ctx, "<i>Reactions are only counted in chats where I am an admin.</i>"))
+	_, err = msg.Reply(b, text.String(), helpers.Shtml())
+	return err
+}
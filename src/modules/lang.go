@@ -0,0 +1,55 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"strings"
+)
+
+// tr translates a message to the language of the user of an update and formats it with args
+func tr(ctx *ext.Context, message string, args ...any) string {
+	return i18n.T(i18n.Lang(ctx.EffectiveUser), message, args...)
+}
+
+// trUser translates a message sent to a user outside of an update, like a notice from a background job
+func trUser(userId int64, message string, args ...any) string {
+	return i18n.T(i18n.UserLang(userId), message, args...)
+}
+
+func setLang(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) == 0 {
+		var langs []string
+		for _, lang := range i18n.Languages() {
+			langs = append(langs, fmt.Sprintf("<code>%s</code> - %s", lang, i18n.T(lang, "language")))
+		}
+
+		text := tr(ctx, "<b>Language:</b> %s\n\nUse <code>/lang code</code> to change it, or <code>/lang auto</code> to follow the language of your Telegram app.\n\n", i18n.T(i18n.Lang(msg.From), "language")) +
+			strings.Join(langs, "\n")
+		_, err := msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	lang := strings.ToLower(args[0])
+	switch {
+	case lang == "auto":
+		i18n.SetLang(msg.From.Id, "")
+	case i18n.Supported(lang):
+		i18n.SetLang(msg.From.Id, lang)
+	default:
+		_, err := msg.Reply(b, tr(ctx, "<code>%s</code> is not an available language, see <code>/lang</code>.", html.EscapeString(args[0])), helpers.Shtml())
+		return err
+	}
+
+	_, err := msg.Reply(b, tr(ctx, "Language set to %s.", tr(ctx, "language")), helpers.Shtml())
+	return err
+}
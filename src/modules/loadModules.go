@@ -3,6 +3,7 @@ package modules
 import (
 	"AshokShau/channelManager/src"
 	"AshokShau/channelManager/src/config"
+	"AshokShau/channelManager/src/modules/utils/i18n"
 	"encoding/json"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
@@ -60,30 +61,54 @@ func loadCallbacks(d *ext.Dispatcher) {
 	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("send."), sendPostCallback))
 	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("delete."), deletePostCallback))
 	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("repost."), repostCallback))
+	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("react."), reactCallback).SetAllowChannel(true))
+	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("alert."), alertCallback).SetAllowChannel(true))
+	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("policy."), policyOverrideCallback))
+	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("import."), importCallback))
+	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("settings."), settingsCallback))
+	d.AddHandler(handlers.NewCallback(callbackquery.Prefix("connect."), connectCallback))
 }
 
 func loadPost(d *ext.Dispatcher) {
-	src.AddCommand(d, []string{"del", "delete"}, deletePost)
-	src.AddCommand(d, []string{"delAll", "deleteAll"}, delAllPosts)
-	src.AddCommand(d, []string{"create", "new", "share"}, createPost)
-	src.AddCommand(d, []string{"getPost", "get"}, getPost)
-	src.AddCommand(d, []string{"send", "post"}, sendPost)
-	src.AddCommand(d, []string{"report"}, repost)
-	src.AddCommand(d, []string{"edit"}, editPost)
-	src.AddCommand(d, []string{"repost"}, repost)
-	src.AddCommand(d, []string{"start"}, start)
-	src.AddCommand(d, []string{"help"}, help)
-
-	src.AddCommand(d, []string{"ban"}, banUser)
-	src.AddCommand(d, []string{"unban"}, unbanUser)
-	src.AddCommand(d, []string{"bans"}, getBans)
-	src.AddCommand(d, []string{"broadcast"}, broadcast)
-	src.AddCommand(d, []string{"stats"}, stats)
-
-	src.AddCommand(d, []string{"disconnect", "remove"}, disconnect)
-	src.AddCommand(d, []string{"connection", "channels"}, connection)
+	src.AddCommand(d, src.Command{Names: []string{"start"}, Description: i18n.N("Start the bot"), Section: src.SectionGeneral}, start)
+	src.AddCommand(d, src.Command{Names: []string{"help"}, Usage: "[command]", Description: i18n.N("List the commands or explain one of them"), Section: src.SectionGeneral}, help)
+
+	src.Register(src.Command{Names: []string{"add"}, Usage: "[chat_id ..]", Description: i18n.N("Connect channels or groups by picking them, by ID or with a forwarded message"), Section: src.SectionConnection})
+	src.Register(src.Command{Names: []string{"cancel"}, Description: i18n.N("Stop connecting a chat with /add"), Section: src.SectionConnection, Scope: src.ScopeHidden})
+	src.AddCommand(d, src.Command{Names: []string{"disconnect", "remove"}, Usage: "chat_id [chat_id2 ..]", Description: i18n.N("Disconnect from channels or groups"), Section: src.SectionConnection}, disconnect)
+	src.AddCommand(d, src.Command{Names: []string{"channels", "connection"}, Description: i18n.N("List all connected chats"), Section: src.SectionConnection}, connection)
+	src.AddCommand(d, src.Command{Names: []string{"check"}, Description: i18n.N("Check the reachability, admins, bot rights and members of every connected chat"), Section: src.SectionConnection}, checkConnections)
+	src.AddCommand(d, src.Command{Names: []string{"footer"}, Usage: "chat_id text|off", Description: i18n.N("Add a footer and default buttons to posts in a chat"), Section: src.SectionConnection}, setFooter)
+	src.AddCommand(d, src.Command{Names: []string{"watermark"}, Usage: "chat_id [position] [opacity]", Description: i18n.N("Reply to a logo to stamp it on photo posts in a chat"), Section: src.SectionConnection}, setWatermark)
+	src.AddCommand(d, src.Command{Names: []string{"policy"}, Usage: "all|chat_id option value", Description: i18n.N("Check posts for banned words, link domains, link count and hashtags before sending"), Section: src.SectionConnection}, setPolicy)
+	src.AddCommand(d, src.Command{Names: []string{"mirror"}, Usage: "add|remove source_id [target_ids]", Description: i18n.N("Copy new posts of a channel to your other chats"), Section: src.SectionConnection}, setMirror)
+	src.AddCommand(d, src.Command{Names: []string{"group"}, Usage: "name chat_id [chat_id2 ..]|off", Description: i18n.N("Name a set of connected chats for feeds"), Section: src.SectionConnection}, setGroup)
+	src.AddCommand(d, src.Command{Names: []string{"feed"}, Usage: "add url [group]", Description: i18n.N("Post new articles of an RSS or Atom feed"), Section: src.SectionConnection}, setFeed)
+	src.AddCommand(d, src.Command{Names: []string{"apikey"}, Usage: "[revoke]", Description: i18n.N("Create a key for the REST API to publish posts from other apps"), Section: src.SectionConnection}, apiKey)
+	src.AddCommand(d, src.Command{Names: []string{"webhook"}, Usage: "add https_url [events]", Description: i18n.N("Get signed events when posts are sent, fail or are deleted"), Section: src.SectionConnection}, setWebhook)
+	src.AddCommand(d, src.Command{Names: []string{"export", "backup"}, Description: i18n.N("Get a backup file of your connections, settings, posts, groups and schedules"), Section: src.SectionConnection}, exportData)
+	src.AddCommand(d, src.Command{Names: []string{"import", "restore"}, Description: i18n.N("Reply to a backup file to restore it, conflicts are shown before anything changes"), Section: src.SectionConnection}, importData)
+
+	src.AddCommand(d, src.Command{Names: []string{"send", "post"}, Usage: "[options]", Description: i18n.N("Reply to a message to send it to all connected chats"), Section: src.SectionPost}, sendPost)
+	src.AddCommand(d, src.Command{Names: []string{"create", "new", "share"}, Usage: "[options]", Description: i18n.N("Reply to a message to save it as a post and get its PostId"), Section: src.SectionPost}, createPost)
+	src.AddCommand(d, src.Command{Names: []string{"get", "getPost"}, Usage: "PostId", Description: i18n.N("Preview a post or share it in the current chat"), Section: src.SectionPost}, getPost)
+	src.AddCommand(d, src.Command{Names: []string{"repost"}, Usage: "PostId [options]", Description: i18n.N("Delete a post from all connected chats and send it again"), Section: src.SectionPost}, repost)
+	src.AddCommand(d, src.Command{Names: []string{"edit"}, Usage: "PostId [options]", Description: i18n.N("Reply to a message to edit a post in all connected chats"), Section: src.SectionPost}, editPost)
+	src.AddCommand(d, src.Command{Names: []string{"del", "delete"}, Usage: "channel_id msg_id", Description: i18n.N("Delete a message from a channel"), Section: src.SectionPost}, deletePost)
+	src.AddCommand(d, src.Command{Names: []string{"delAll", "deleteAll"}, Usage: "PostId", Description: i18n.N("Delete a post from all chats it was sent to"), Section: src.SectionPost}, delAllPosts)
+	src.AddCommand(d, src.Command{Names: []string{"pin"}, Usage: "PostId [silent] [unpin_after]|off", Description: i18n.N("Pin a post in all chats, also when it is sent or reposted"), Section: src.SectionPost}, pinPost)
+	src.AddCommand(d, src.Command{Names: []string{"clicks"}, Usage: "PostId", Description: i18n.N("Show button clicks of a post per chat"), Section: src.SectionPost}, clicks)
+	src.AddCommand(d, src.Command{Names: []string{"insights"}, Usage: "PostId", Description: i18n.N("Show reactions of a post per chat and channel totals"), Section: src.SectionPost}, insights)
+	src.AddCommand(d, src.Command{Names: []string{"comment"}, Usage: "PostId text|off", Description: i18n.N("Post a comment under a post in linked discussion groups"), Section: src.SectionPost}, setComment)
+
+	src.AddCommand(d, src.Command{Names: []string{"ban"}, Usage: "user_id", Description: i18n.N("Ban a user from using the bot"), Section: src.SectionOwner, Scope: src.ScopeOwner, Permission: src.PermOwner}, banUser)
+	src.AddCommand(d, src.Command{Names: []string{"unban"}, Usage: "user_id", Description: i18n.N("Unban a user"), Section: src.SectionOwner, Scope: src.ScopeOwner, Permission: src.PermOwner}, unbanUser)
+	src.AddCommand(d, src.Command{Names: []string{"bans"}, Description: i18n.N("List the banned users"), Section: src.SectionOwner, Scope: src.ScopeOwner, Permission: src.PermOwner}, getBans)
+	src.AddCommand(d, src.Command{Names: []string{"broadcast"}, Description: i18n.N("Reply to a message to send it to all users"), Section: src.SectionOwner, Scope: src.ScopeOwner, Permission: src.PermOwner}, broadcast)
+	src.AddCommand(d, src.Command{Names: []string{"stats"}, Description: i18n.N("Show the number of users"), Section: src.SectionOwner, Scope: src.ScopeOwner, Permission: src.PermOwner}, stats)
+
 	d.AddHandler(handlers.NewConversation(
-		[]ext.Handler{handlers.NewCommand("add", connect)},
+		[]ext.Handler{handlers.NewCommand("add", connect), handlers.NewMessage(isChatShared, chatShared)},
 		map[string][]ext.Handler{
 			CHATID: {handlers.NewMessage(noCommands, askChatID)},
 		},
@@ -95,14 +120,23 @@ func loadPost(d *ext.Dispatcher) {
 	))
 
 	d.AddHandler(handlers.NewInlineQuery(inlinequery.All, inlineSharePost))
+	d.AddHandler(reactionCountHandler{response: reactionCountUpdate})
+	d.AddHandler(handlers.NewMyChatMember(nil, myChatMember))
+	d.AddHandler(handlers.NewMessage(isAutomaticForward, automaticForward))
+	d.AddHandler(handlers.NewMessage(isChannelPost, mirrorPost).SetAllowChannel(true))
+	d.AddHandler(handlers.NewMessage(isEditedChannelPost, mirrorEdit).SetAllowChannel(true).SetAllowEdited(true))
 }
 
 func loadSettings(d *ext.Dispatcher) {
-	src.AddCommand(d, []string{"forwardTag", "forward"}, updateForwardTag)
-	src.AddCommand(d, []string{"noNotif", "notify", "silent"}, updateNoNotif)
-	src.AddCommand(d, []string{"protect"}, updateProtect)
-	src.AddCommand(d, []string{"spoiler"}, updateSpoiler)
-	src.AddCommand(d, []string{"webPreview", "preview"}, updateWebPreview)
-	src.AddCommand(d, []string{"captionAbove"}, updateCaptionAbove)
-	src.AddCommand(d, []string{"reset"}, resetSettings)
+	src.AddCommand(d, src.Command{Names: []string{"settings"}, Description: i18n.N("Open a panel to change every setting, chat overrides and your timezone"), Section: src.SectionSettings}, settingsPanel)
+	src.AddCommand(d, src.Command{Names: []string{"forward", "forwardTag"}, Usage: "on|off", Description: i18n.N("Toggle the forward tag"), Section: src.SectionSettings}, updateForwardTag)
+	src.AddCommand(d, src.Command{Names: []string{"silent", "noNotif", "notify"}, Usage: "on|off", Description: i18n.N("Toggle sending without notification"), Section: src.SectionSettings}, updateNoNotif)
+	src.AddCommand(d, src.Command{Names: []string{"protect"}, Usage: "on|off", Description: i18n.N("Toggle protecting the content from forwarding and saving"), Section: src.SectionSettings}, updateProtect)
+	src.AddCommand(d, src.Command{Names: []string{"spoiler"}, Usage: "on|off", Description: i18n.N("Toggle hiding media under a spoiler"), Section: src.SectionSettings}, updateSpoiler)
+	src.AddCommand(d, src.Command{Names: []string{"preview", "webPreview"}, Usage: "on|off", Description: i18n.N("Toggle link previews"), Section: src.SectionSettings}, updateWebPreview)
+	src.AddCommand(d, src.Command{Names: []string{"captionAbove"}, Usage: "on|off", Description: i18n.N("Toggle showing captions above media"), Section: src.SectionSettings}, updateCaptionAbove)
+	src.AddCommand(d, src.Command{Names: []string{"noTracking", "noTrack"}, Usage: "on|off", Description: i18n.N("Toggle button click tracking off"), Section: src.SectionSettings}, updateNoTracking)
+	src.AddCommand(d, src.Command{Names: []string{"reset"}, Description: i18n.N("Reset all user settings to off"), Section: src.SectionSettings}, resetSettings)
+	src.AddCommand(d, src.Command{Names: []string{"chatsettings", "chatSettings"}, Usage: "chat_id [setting on|off|default]", Description: i18n.N("Override your settings in one chat"), Section: src.SectionSettings}, chatSettings)
+	src.AddCommand(d, src.Command{Names: []string{"lang", "language"}, Usage: "[code|auto]", Description: i18n.N("Choose the language of my messages, by default the one of your Telegram app"), Section: src.SectionSettings}, setLang)
 }
@@ -0,0 +1,117 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+)
+
+// myChatMember handles changes of the bot's own membership in a chat
+func myChatMember(b *gotgbot.Bot, ctx *ext.Context) error {
+	update := ctx.MyChatMember
+	wasAdmin := isAdminStatus(update.OldChatMember.GetStatus())
+	isAdmin := isAdminStatus(update.NewChatMember.GetStatus())
+
+	switch {
+	case !wasAdmin && isAdmin:
+		// Reload the admins so the rights of the bot are checked against the promotion
+		onlyAdmins.LoadAdminCache(b, update.Chat.Id)
+		if update.Chat.Type == "channel" {
+			offerConnection(b, update)
+		}
+	case wasAdmin && !isAdmin:
+		dropChat(b, update.Chat)
+	case isAdmin:
+		// Only the rights of the bot changed
+		onlyAdmins.LoadAdminCache(b, update.Chat.Id)
+	}
+	return ext.EndGroups
+}
+
+// isAdminStatus reports whether a chat member status is one of an admin
+func isAdminStatus(status string) bool {
+	return status == "administrator" || status == "creator"
+}
+
+// offerConnection sends the user who promoted the bot a button to connect the channel
+func offerConnection(b *gotgbot.Bot, update *gotgbot.ChatMemberUpdated) {
+	userId := update.From.Id
+	if db.IsUserBanned(userId) || helpers.Contains(db.Connection(userId).ChatIds, update.Chat.Id) {
+		return
+	}
+
+	button := &gotgbot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: trUser(userId, "🔗 Connect this channel"), CallbackData: fmt.Sprintf("connect.%d", update.Chat.Id)}},
+		},
+	}
+
+	text := trUser(userId, "You made me an admin of <b>%s</b> (<code>%d</code>). Connect it to send posts there?", html.EscapeString(update.Chat.Title), update.Chat.Id)
+	if _, err := b.SendMessage(userId, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyMarkup: button}); err != nil {
+		// The user may never have started the bot
+		log.Printf("[membership] Failed to offer the connection of %d to %d: %v", update.Chat.Id, userId, err)
+	}
+}
+
+// connectCallback connects the chat of a button sent by offerConnection
+func connectCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.Update.CallbackQuery
+	chatId, err := strconv.ParseInt(strings.TrimPrefix(query.Data, "connect."), 10, 64)
+	if err != nil {
+		_, _ = query.Answer(b, nil)
+		return nil
+	}
+
+	getChat, failure := checkChatAdmin(b, chatId, query.From.Id)
+	if failure != "" {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, failure), ShowAlert: true})
+		return nil
+	}
+
+	db.ConnectId(query.From.Id, chatId)
+	_, _ = query.Answer(b, nil)
+	_, _, err = b.EditMessageText(tr(ctx, "You are now connected to %s (%d)", html.EscapeString(getChat.ChatInfo.Title), chatId), &gotgbot.EditMessageTextOpts{
+		ChatId:    query.Message.GetChat().Id,
+		MessageId: query.Message.GetMessageId(),
+		ParseMode: gotgbot.ParseModeHTML,
+	})
+	return err
+}
+
+// dropChat disconnects a chat the bot is no longer an admin of from all users, and tells each of them
+// which of their posts there can no longer be managed
+func dropChat(b *gotgbot.Bot, chat gotgbot.Chat) {
+	userIds, err := db.ConnectedUsers(chat.Id)
+	if err != nil {
+		return
+	}
+
+	for _, userId := range userIds {
+		db.DisconnectId(userId, chat.Id)
+		emitEvent(userId, eventConnectionLost, map[string]any{"chat_id": chat.Id, "reason": rightFailures[onlyAdmins.NotAdmin][1]})
+
+		text := trUser(userId, "⚠️ I am no longer an admin of <b>%s</b> (<code>%d</code>), so it was disconnected.", html.EscapeString(chat.Title), chat.Id)
+		if posts, err := db.ListChatPosts(userId, chat.Id); err == nil && len(posts) > 0 {
+			lines := make([]string, 0, len(posts))
+			for _, post := range posts {
+				lines = append(lines, fmt.Sprintf("<code>%s</code>", post.PostId))
+			}
+			text += trUser(userId, "\n\nThese posts can no longer be edited, reposted or deleted there:\n%s", strings.Join(shortList(i18n.UserLang(userId), lines), "\n"))
+		}
+
+		if _, err = b.SendMessage(userId, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML}); err != nil {
+			log.Printf("[membership] Failed to tell %d that %d was disconnected: %v", userId, chat.Id, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
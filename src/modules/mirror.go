@@ -0,0 +1,272 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func isChannelPost(msg *gotgbot.Message) bool {
+	return msg.Chat.Type == "channel"
+}
+
+func isEditedChannelPost(msg *gotgbot.Message) bool {
+	return msg.Chat.Type == "channel" && msg.EditDate != 0
+}
+
+// mirrorTargets returns the chats a mirror copies to that the user is still connected to
+func mirrorTargets(mirror db.Mirror) []int64 {
+	connected := db.Connection(mirror.UserId).ChatIds
+	if !helpers.Contains(connected, mirror.SourceId) {
+		return nil
+	}
+
+	targets := mirror.Targets
+	if len(targets) == 0 {
+		targets = connected
+	}
+
+	var chatIds []int64
+	for _, chatId := range targets {
+		if chatId != mirror.SourceId && helpers.Contains(connected, chatId) {
+			chatIds = append(chatIds, chatId)
+		}
+	}
+	return chatIds
+}
+
+// mirrorPost copies a new post of a mirror source to the mirror's target chats
+func mirrorPost(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	mirrors, err := db.GetMirrors(msg.Chat.Id)
+	if err != nil || len(mirrors) == 0 {
+		return err
+	}
+
+	postText, dataType, fileId, buttons, errorMsg := helpers.GetMessageContent(msg)
+	if dataType == -1 {
+		log.Printf("[mirror] Skipping message %d of chat %d: %s", msg.MessageId, msg.Chat.Id, errorMsg)
+		return nil
+	}
+
+	for _, mirror := range mirrors {
+		chatIds, refused := permittedChats(b, mirror.UserId, mirrorTargets(mirror), onlyAdmins.CanPostMessages)
+		if len(chatIds) == 0 && len(refused) == 0 {
+			continue
+		}
+		intro := trUser(mirror.UserId, "🚫 <b>A post of %s was not mirrored, it breaks your content policies:</b>\n\n", html.EscapeString(msg.Chat.Title))
+		if policyBlocked(b, mirror.UserId, chatIds, postText, buttons, false, intro) {
+			continue
+		}
+
+		postId := helpers.GenerateUniqueString()
+		userSetting := db.GetUserSettings(mirror.UserId)
+		marks := make(watermarkCache)
+		var failedChats []string
+		sent := 0
+		for _, chatId := range chatIds {
+			message, err := sendPostTo(b, ctx, mirror.UserId, chatId, postId, dataType, postText, fileId, buttons, userSetting, marks)
+			if err != nil {
+				log.Printf("[mirror] Failed to send post to chat %d: %v", chatId, err)
+				emitFailed(mirror.UserId, postId, chatId, err)
+				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
+				continue
+			}
+
+			_, _ = db.AddPost(postId, mirror.UserId, chatId, message.MessageId, dataType, fileId, buttons, postText)
+			emitSent(mirror.UserId, postId, message)
+			sent++
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		if sent == 0 && len(refused) == 0 {
+			continue
+		}
+		if sent > 0 {
+			_ = db.SetPostSource(postId, msg.Chat.Id, msg.MessageId)
+		}
+
+		lang := i18n.UserLang(mirror.UserId)
+		text := trUser(mirror.UserId, "🔁 Mirrored a post of <b>%s</b> to %d chats.\n", html.EscapeString(msg.Chat.Title), sent)
+		if len(failedChats) > 0 {
+			text += trUser(mirror.UserId, "❌ Failed to send to: %s\n", strings.Join(failedChats, ", "))
+		}
+		if len(refused) > 0 {
+			text += trUser(mirror.UserId, "🚫 Skipped, admin rights are missing: %s\n", strings.Join(refusalLines(lang, refused), ", "))
+		}
+		opts := &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, DisableNotification: true}
+		if sent > 0 {
+			text += trUser(mirror.UserId, "\n<b>PostId:</b> <code>%s</code>", postId)
+			opts.ReplyMarkup = helpers.PostButton(lang, postId)
+		}
+		_, _ = b.SendMessage(mirror.UserId, text, opts)
+	}
+	return nil
+}
+
+// mirrorEdit applies an edit of a mirror source post to its mirrored copies
+func mirrorEdit(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	posts, err := db.GetPostsBySource(msg.Chat.Id, msg.MessageId)
+	if err != nil || len(posts) == 0 {
+		return err
+	}
+
+	postText, dataType, fileId, buttons, errorMsg := helpers.GetMessageContent(msg)
+	if dataType == -1 {
+		log.Printf("[mirror] Skipping edit of message %d of chat %d: %s", msg.MessageId, msg.Chat.Id, errorMsg)
+		return nil
+	}
+
+	// Posts edited with !edit keep their source, edit each message once with its latest post
+	sort.Slice(posts, func(i, j int) bool { return posts[i].PostId > posts[j].PostId })
+	edited := make(map[string]bool)
+
+	for _, post := range posts {
+		if post.FilterReply == postText && post.FileID == fileId && helpers.RevertButtons(post.Buttons) == helpers.RevertButtons(buttons) {
+			continue
+		}
+
+		var postChats []int64
+		for _, chat := range post.Chats {
+			if !chat.Comment {
+				postChats = append(postChats, chat.ChatId)
+			}
+		}
+		intro := trUser(post.UserId, "🚫 <b>An edit of a post of %s was not mirrored, it breaks your content policies:</b>\n\n", html.EscapeString(msg.Chat.Title))
+		if policyBlocked(b, post.UserId, postChats, postText, buttons, false, intro) {
+			continue
+		}
+
+		userSetting := db.GetUserSettings(post.UserId)
+		marks := make(watermarkCache)
+		var failedChats []string
+		done := 0
+		for _, chat := range post.Chats {
+			key := commentKey(chat.ChatId, chat.MsgId)
+			if chat.Comment || edited[key] {
+				continue
+			}
+			edited[key] = true
+
+			if _, refused := permittedChats(b, post.UserId, []int64{chat.ChatId}, onlyAdmins.CanEditMessages); len(refused) > 0 {
+				failedChats = append(failedChats, refusalLines(i18n.UserLang(post.UserId), refused)...)
+				continue
+			}
+
+			chatSetting := db.GetChatSettings(post.UserId, chat.ChatId)
+			err = editPostMessage(b, chatSetting, chat.MsgId, post.MsgType, post.PostId, dataType, postText, fileId, buttons, userSetting, marks)
+			if err != nil {
+				log.Printf("[mirror] Failed to edit message %d in chat %d: %v", chat.MsgId, chat.ChatId, err)
+				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chat.ChatId))
+				continue
+			}
+
+			_, _ = db.AddPost(post.PostId, post.UserId, chat.ChatId, chat.MsgId, dataType, fileId, buttons, postText)
+			done++
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		if done > 0 {
+			_ = db.AddPostVersions(post.PostId, post.Version(msg.EditDate))
+		}
+
+		if len(failedChats) > 0 {
+			text := trUser(post.UserId, "✏️ An edit of a post of <b>%s</b> could not be mirrored to: %s\n\n<b>PostId:</b> <code>%s</code>",
+				html.EscapeString(msg.Chat.Title), strings.Join(failedChats, ", "), post.PostId)
+			_, _ = b.SendMessage(post.UserId, text, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, DisableNotification: true})
+		}
+	}
+	return nil
+}
+
+func setMirror(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 2 {
+		mirrors, _ := db.ListMirrors(msg.From.Id)
+		var text strings.Builder
+		text.WriteString(tr(ctx, "New posts of a source channel are copied to your other chats automatically.\n"+
+			"Usage: <code>!mirror add source_id [target_ids]</code>\n"+
+			"Without target ids, posts are copied to all connected chats.\n"+
+			"Use <code>!mirror remove source_id</code> to stop mirroring.\n"))
+
+		if len(mirrors) > 0 {
+			text.WriteString(tr(ctx, "\n<b>Your mirrors:</b>\n"))
+			for _, mirror := range mirrors {
+				targets := tr(ctx, "all chats")
+				if len(mirror.Targets) > 0 {
+					targets = strings.Trim(fmt.Sprint(mirror.Targets), "[]")
+				}
+				text.WriteString(fmt.Sprintf("<code>%d</code> → %s\n", mirror.SourceId, targets))
+			}
+		}
+
+		_, err := msg.Reply(b, text.String(), helpers.Shtml())
+		return err
+	}
+
+	connected := db.Connection(msg.From.Id).ChatIds
+	var chatIds []int64
+	for _, arg := range args[1:] {
+		chatId, err := strconv.ParseInt(arg, 10, 64)
+		// Sources can be removed after disconnecting them
+		if err != nil || (args[0] == "add" && !helpers.Contains(connected, chatId)) {
+			_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is not one of your connected chats.", html.EscapeString(arg)), helpers.Shtml())
+			return err
+		}
+		chatIds = append(chatIds, chatId)
+	}
+	sourceId := chatIds[0]
+
+	switch args[0] {
+	case "add":
+		getChat := onlyAdmins.GetChatCache(sourceId)
+		if !getChat.Cached {
+			getChat = onlyAdmins.LoadChatCache(b, sourceId)
+		}
+		if getChat.ChatInfo.Type != "channel" {
+			_, err := msg.Reply(b, tr(ctx, "Only channels can be mirror sources."), helpers.Shtml())
+			return err
+		}
+
+		var targets []int64
+		for _, chatId := range chatIds[1:] {
+			if chatId != sourceId && !helpers.Contains(targets, chatId) {
+				targets = append(targets, chatId)
+			}
+		}
+
+		if err := db.SetMirror(msg.From.Id, sourceId, targets); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error saving the mirror."), helpers.Shtml())
+			return err
+		}
+
+		_, err := msg.Reply(b, tr(ctx, "New posts of <code>%d</code> will be copied to %d chats.", sourceId, len(mirrorTargets(db.Mirror{UserId: msg.From.Id, SourceId: sourceId, Targets: targets}))), helpers.Shtml())
+		return err
+	case "remove", "off":
+		if err := db.RemoveMirror(msg.From.Id, sourceId); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the mirror."), helpers.Shtml())
+			return err
+		}
+		_, err := msg.Reply(b, tr(ctx, "Posts of <code>%d</code> are no longer mirrored.", sourceId), helpers.Shtml())
+		return err
+	default:
+		_, err := msg.Reply(b, tr(ctx, "Please use <code>!mirror add</code> or <code>!mirror remove</code>."), helpers.Shtml())
+		return err
+	}
+}
@@ -0,0 +1,157 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"log"
+	"strings"
+	"time"
+)
+
+// pinMessage pins a delivered post message and schedules its unpin
+func pinMessage(b *gotgbot.Bot, chatId, msgId int64, pin *db.PinOptions) error {
+	_, err := b.PinChatMessage(chatId, msgId, &gotgbot.PinChatMessageOpts{DisableNotification: pin.Silent})
+	if err != nil {
+		return err
+	}
+
+	if pin.UnpinAfter > 0 {
+		_ = db.AddUnpin(chatId, msgId, time.Now().Unix()+pin.UnpinAfter)
+	}
+	return nil
+}
+
+// pinFailure describes a failed pin for the post summary
+func pinFailure(lang string, chatId int64, err error) string {
+	reason := err.Error()
+	if strings.Contains(reason, "not enough rights") || strings.Contains(reason, "CHAT_ADMIN_REQUIRED") {
+		reason = i18n.T(lang, "missing the right to pin messages")
+	}
+	return fmt.Sprintf("<code>%d</code> (%s)", chatId, reason)
+}
+
+// pinSummary is the part of a post summary that lists the chats the post could not be pinned in
+func pinSummary(lang string, pinFailed []string) string {
+	if len(pinFailed) == 0 {
+		return ""
+	}
+	return i18n.T(lang, "📌 <b>Failed to pin in %d chats:</b>\n%s\n\n", len(pinFailed), strings.Join(pinFailed, "\n"))
+}
+
+func formatPin(lang string, pin *db.PinOptions) string {
+	text := i18n.T(lang, "pinned")
+	if pin.Silent {
+		text = i18n.T(lang, "pinned silently")
+	}
+	if pin.UnpinAfter > 0 {
+		text += i18n.T(lang, ", unpinned after %s", time.Duration(pin.UnpinAfter)*time.Second)
+	}
+	return text
+}
+
+// runUnpins unpins the post messages whose pin duration is over
+func runUnpins(b *gotgbot.Bot) {
+	unpins, err := db.DueUnpins(time.Now().Unix())
+	if err != nil {
+		log.Printf("[pin] DueUnpins: %v", err)
+		return
+	}
+
+	for _, unpin := range unpins {
+		if _, err = b.UnpinChatMessage(unpin.ChatId, &gotgbot.UnpinChatMessageOpts{MessageId: &unpin.MsgId}); err != nil {
+			log.Printf("[pin] Failed to unpin %d in chat %d: %v", unpin.MsgId, unpin.ChatId, err)
+		}
+		_ = db.RemoveUnpin(unpin.ChatId, unpin.MsgId)
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func pinPost(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		text := tr(ctx, "Please provide a PostId to pin it in all chats it was sent to. The post is pinned again when it is sent or reposted.\n"+
+			"Usage: <code>!pin PostId [silent] [unpin_after]</code>\n\n"+
+			"<code>silent</code> - Pin without notifying members\n"+
+			"<code>unpin_after</code> - Unpin after a duration, e.g. <code>12h</code> or <code>3d</code>\n\n"+
+			"Use <code>!pin PostId off</code> to unpin it.")
+		_, err := msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	post, err := db.GetPost(args[0])
+	if err != nil || post == nil || post.UserId != msg.From.Id {
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or error retrieving post."), helpers.Shtml())
+		return err
+	}
+
+	var pin *db.PinOptions
+	if len(args) < 2 || args[1] != "off" {
+		pin = &db.PinOptions{}
+		for _, arg := range args[1:] {
+			if arg == "silent" {
+				pin.Silent = true
+				continue
+			}
+
+			duration, err := helpers.ParseDuration(arg)
+			if err != nil {
+				_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is neither <code>silent</code> nor a duration like <code>12h</code> or <code>3d</code>.", html.EscapeString(arg)), helpers.Shtml())
+				return err
+			}
+			pin.UnpinAfter = int64(duration.Seconds())
+		}
+	}
+
+	if err = db.SetPostPin(post.PostId, pin); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the pin options."), helpers.Shtml())
+		return err
+	}
+
+	lang := i18n.Lang(ctx.EffectiveUser)
+	var done int
+	var failed []string
+	for _, chat := range post.Chats {
+		// Previews from !create live in the chat with the bot
+		if chat.Comment || chat.ChatId == b.Id {
+			continue
+		}
+
+		if pin == nil {
+			_, err = b.UnpinChatMessage(chat.ChatId, &gotgbot.UnpinChatMessageOpts{MessageId: &chat.MsgId})
+			_ = db.RemoveUnpin(chat.ChatId, chat.MsgId)
+		} else {
+			err = pinMessage(b, chat.ChatId, chat.MsgId, pin)
+		}
+
+		if err != nil {
+			failed = append(failed, pinFailure(lang, chat.ChatId, err))
+		} else {
+			done++
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	var text string
+	if pin == nil {
+		text = tr(ctx, "Post unpinned in %d chats.\n\n", done)
+		if len(failed) > 0 {
+			text += tr(ctx, "❌ <b>Failed to unpin in %d chats:</b>\n%s", len(failed), strings.Join(failed, "\n"))
+		}
+	} else {
+		text = tr(ctx, "Post %s in %d chats, it will also be pinned when it is sent or reposted.\n\n", formatPin(lang, pin), done)
+		text += pinSummary(lang, failed)
+	}
+
+	_, err = msg.Reply(b, text, helpers.Shtml())
+	return err
+}
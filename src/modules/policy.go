@@ -0,0 +1,272 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// policyOverrideTimeout is how long the "Send anyway" button of a policy report works
+const policyOverrideTimeout = 10 * time.Minute
+
+// policyDelivery sends a checked post to its checked chats. It captures the post and the targets when the
+// policies run, so an override sends what was checked without reading the post or the update again.
+type policyDelivery func(b *gotgbot.Bot) error
+
+type policyOverride struct {
+	UserId  int64
+	Deliver policyDelivery
+}
+
+// policyOverrides holds the deliveries waiting for an override confirmation
+var policyOverrides = struct {
+	sync.Mutex
+	pending map[string]policyOverride
+}{pending: make(map[string]policyOverride)}
+
+// policyReport checks a post against the policies of the target chats. It returns the violations as HTML,
+// empty if there are none, and whether all violated policies allow sending the post anyway.
+func policyReport(lang string, userId int64, chatIds []int64, text string, buttons []db.Button) (string, bool) {
+	var report strings.Builder
+	confirm := true
+	if policy := db.GetPolicy(userId, 0); policy != nil {
+		if violations := helpers.CheckPolicy(lang, policy, text, buttons); len(violations) > 0 {
+			report.WriteString(i18n.T(lang, "<b>All chats:</b>\n- %s\n\n", strings.Join(violations, "\n- ")))
+			confirm = confirm && policy.Confirm
+		}
+	}
+
+	for _, chatId := range chatIds {
+		policy := db.GetPolicy(userId, chatId)
+		if policy == nil {
+			continue
+		}
+		if violations := helpers.CheckPolicy(lang, policy, text, buttons); len(violations) > 0 {
+			report.WriteString(fmt.Sprintf("<b>%d:</b>\n- %s\n\n", chatId, strings.Join(violations, "\n- ")))
+			confirm = confirm && policy.Confirm
+		}
+	}
+	return report.String(), confirm
+}
+
+// policyBlocked checks a post that is sent without a command, where nobody is there to send it anyway.
+// If it breaks a policy the user gets the report after intro, and the post must not be sent. With force
+// the post is sent when all violated policies allow it, like a forced API send.
+func policyBlocked(b *gotgbot.Bot, userId int64, chatIds []int64, text string, buttons []db.Button, force bool, intro string) bool {
+	report, confirm := policyReport(i18n.UserLang(userId), userId, chatIds, text, buttons)
+	if report == "" || (force && confirm) {
+		return false
+	}
+
+	_, _ = b.SendMessage(userId, intro+report, &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, DisableNotification: true})
+	return true
+}
+
+// policyGate runs the policies of the target chats on a post. If any check fails it replies with a report and returns
+// false. When all violated policies allow it, the report has a button that runs deliver without the checks.
+func policyGate(b *gotgbot.Bot, ctx *ext.Context, userId int64, chatIds []int64, text string, buttons []db.Button, deliver policyDelivery) bool {
+	msg := ctx.EffectiveMessage
+	key := fmt.Sprintf("%d:%d", msg.Chat.Id, msg.MessageId)
+
+	report, confirm := policyReport(i18n.Lang(ctx.EffectiveUser), userId, chatIds, text, buttons)
+	if report == "" {
+		return true
+	}
+
+	text = tr(ctx, "🚫 <b>This post breaks your content policies:</b>\n\n") + report
+	opts := &gotgbot.SendMessageOpts{ParseMode: gotgbot.ParseModeHTML, ReplyParameters: &gotgbot.ReplyParameters{AllowSendingWithoutReply: true}}
+	if confirm {
+		text += tr(ctx, "Check the post and send it anyway if it is fine.")
+		opts.ReplyMarkup = gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
+			{{Text: tr(ctx, "⚠️ Send anyway"), CallbackData: "policy." + key}},
+		}}
+
+		policyOverrides.Lock()
+		policyOverrides.pending[key] = policyOverride{UserId: userId, Deliver: deliver}
+		policyOverrides.Unlock()
+		time.AfterFunc(policyOverrideTimeout, func() {
+			policyOverrides.Lock()
+			delete(policyOverrides.pending, key)
+			policyOverrides.Unlock()
+		})
+	} else {
+		text += tr(ctx, "Fix the post and try again.")
+	}
+
+	_, _ = msg.Reply(b, text, opts)
+	return false
+}
+
+// policyOverrideCallback sends a post blocked by a policy to the chats it was checked for
+func policyOverrideCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.Update.CallbackQuery
+	key := strings.TrimPrefix(query.Data, "policy.")
+
+	policyOverrides.Lock()
+	override, ok := policyOverrides.pending[key]
+	if ok && override.UserId == query.From.Id {
+		delete(policyOverrides.pending, key)
+	}
+	policyOverrides.Unlock()
+
+	if !ok || override.UserId != query.From.Id {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "This confirmation has expired. Please send the post again."), ShowAlert: true})
+		return nil
+	}
+
+	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "📤 Sending post anyway...")})
+	_, _, _ = b.EditMessageReplyMarkup(&gotgbot.EditMessageReplyMarkupOpts{
+		ChatId:    query.Message.GetChat().Id,
+		MessageId: query.Message.GetMessageId(),
+	})
+	return override.Deliver(b)
+}
+
+// parseList splits a comma separated list into lowercase items
+func parseList(raw string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func formatPolicy(lang string, policy *db.Policy) string {
+	if policy == nil {
+		return i18n.T(lang, "No policy.\n")
+	}
+
+	list := func(items []string) string {
+		if len(items) == 0 {
+			return "-"
+		}
+		return "<code>" + html.EscapeString(strings.Join(items, ", ")) + "</code>"
+	}
+
+	maxLinks := "-"
+	if policy.MaxLinks != nil {
+		maxLinks = strconv.Itoa(*policy.MaxLinks)
+	}
+
+	mode := "block"
+	if policy.Confirm {
+		mode = "confirm"
+	}
+
+	return i18n.T(lang, "Banned words: %s\nAllowed domains: %s\nDenied domains: %s\nMax links: %s\nRequired hashtags: %s\nMode: %s\n",
+		list(policy.BannedWords), list(policy.AllowDomains), list(policy.DenyDomains), maxLinks, list(policy.RequiredTags), mode)
+}
+
+func setPolicy(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		text := tr(ctx, "Content policies are checked before a post is sent, for all chats or one chat.\n"+
+			"Usage: <code>!policy all|chat_id option value</code>\n\n"+
+			"<code>words</code> - Banned words or phrases\n"+
+			"<code>allow</code> - Allowed link domains\n"+
+			"<code>deny</code> - Denied link domains\n"+
+			"<code>links</code> - Maximum number of links\n"+
+			"<code>tags</code> - Required hashtags\n"+
+			"<code>mode</code> - <code>block</code> stops the send, <code>confirm</code> lets you send anyway\n\n"+
+			"Lists are comma separated. "+
+			"Use <code>off</code> as value to remove an option, <code>!policy all|chat_id reset</code> to remove the policy "+
+			"and <code>!policy all|chat_id</code> to view it.")
+		_, err := msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	var chatId int64
+	target := tr(ctx, "all chats")
+	if args[0] != "all" {
+		var err error
+		chatId, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil || !helpers.Contains(db.Connection(msg.From.Id).ChatIds, chatId) {
+			_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is not one of your connected chats.", html.EscapeString(args[0])), helpers.Shtml())
+			return err
+		}
+		target = fmt.Sprintf("<code>%d</code>", chatId)
+	}
+
+	if len(args) == 1 {
+		_, err := msg.Reply(b, tr(ctx, "<b>Policy for %s:</b>\n\n%s", target, formatPolicy(i18n.Lang(msg.From), db.GetPolicy(msg.From.Id, chatId))), helpers.Shtml())
+		return err
+	}
+
+	option := strings.ToLower(args[1])
+	if option == "reset" {
+		if err := db.RemovePolicy(msg.From.Id, chatId); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the policy."), helpers.Shtml())
+			return err
+		}
+		_, err := msg.Reply(b, tr(ctx, "Policy for %s removed.", target), helpers.Shtml())
+		return err
+	}
+
+	if len(args) < 3 {
+		_, err := msg.Reply(b, tr(ctx, "Please provide a value for <code>%s</code>.", html.EscapeString(option)), helpers.Shtml())
+		return err
+	}
+
+	raw := strings.Join(args[2:], " ")
+	var field string
+	var value interface{}
+	switch option {
+	case "words":
+		field, value = "banned_words", parseList(raw)
+	case "allow":
+		field, value = "allow_domains", parseList(raw)
+	case "deny":
+		field, value = "deny_domains", parseList(raw)
+	case "tags":
+		tags := parseList(raw)
+		for i, tag := range tags {
+			if !strings.HasPrefix(tag, "#") {
+				tags[i] = "#" + tag
+			}
+		}
+		field, value = "required_tags", tags
+	case "links":
+		maxLinks, err := strconv.Atoi(raw)
+		if raw != "off" && (err != nil || maxLinks < 0) {
+			_, err = msg.Reply(b, tr(ctx, "The maximum number of links must be 0 or more."), helpers.Shtml())
+			return err
+		}
+		field, value = "max_links", maxLinks
+	case "mode":
+		if raw != "block" && raw != "confirm" {
+			_, err := msg.Reply(b, tr(ctx, "The mode must be <code>block</code> or <code>confirm</code>."), helpers.Shtml())
+			return err
+		}
+		field, value = "confirm", raw == "confirm"
+	default:
+		_, err := msg.Reply(b, tr(ctx, "Unknown option <code>%s</code>. Use <code>!policy</code> to see the options.", html.EscapeString(option)), helpers.Shtml())
+		return err
+	}
+
+	if raw == "off" {
+		value = nil
+	}
+
+	if err := db.SetPolicyField(msg.From.Id, chatId, field, value); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the policy."), helpers.Shtml())
+		return err
+	}
+
+	_, err := msg.Reply(b, tr(ctx, "<b>Policy for %s updated:</b>\n\n%s", target, formatPolicy(i18n.Lang(msg.From), db.GetPolicy(msg.From.Id, chatId))), helpers.Shtml())
+	return err
+}
@@ -0,0 +1,99 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reactionDebounce is how long vote counter edits on a message are held back, so busy posts don't hit flood limits.
+const reactionDebounce = 3 * time.Second
+
+// reactionUpdates holds the latest keyboard of each message with a queued counter refresh
+var reactionUpdates = struct {
+	sync.Mutex
+	pending map[string]gotgbot.InlineKeyboardMarkup
+}{pending: make(map[string]gotgbot.InlineKeyboardMarkup)}
+
+func reactCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.Update.CallbackQuery
+	if query.Message == nil {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "Reactions are only counted on channel posts.")})
+		return nil
+	}
+
+	chatId := query.Message.GetChat().Id
+	msgId := query.Message.GetMessageId()
+	post, err := db.GetPostByMessage(chatId, msgId)
+	if err != nil || post == nil {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "Reactions are only counted on delivered posts.")})
+		return err
+	}
+
+	index := helpers.ToInt(strings.Split(query.Data, ".")[1])
+	if index < 0 || index >= len(post.Buttons) || post.Buttons[index].Type != db.ButtonReact {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "This reaction is no longer available.")})
+		return nil
+	}
+
+	btn := post.Buttons[index]
+	voted, err := db.ToggleVote(post.PostId, chatId, msgId, query.From.Id, helpers.ReactionKey(btn))
+	if err != nil {
+		_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(ctx, "Failed to save your reaction, please try again.")})
+		return err
+	}
+
+	text := tr(ctx, "Your reaction was removed.")
+	if voted {
+		text = tr(ctx, "You reacted %s", btn.Name)
+	}
+	_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: text})
+
+	// The counters are set on the keyboard as it was sent, which has the chat's buttons and tracked links
+	if message, ok := query.Message.(gotgbot.Message); ok && message.ReplyMarkup != nil {
+		scheduleReactionUpdate(b, chatId, msgId, *message.ReplyMarkup, post.Buttons)
+	}
+	return nil
+}
+
+// scheduleReactionUpdate queues a counter refresh for a post message.
+// Taps within reactionDebounce of a queued refresh are folded into it, with the latest keyboard.
+func scheduleReactionUpdate(b *gotgbot.Bot, chatId, msgId int64, markup gotgbot.InlineKeyboardMarkup, buttons []db.Button) {
+	key := fmt.Sprintf("%d:%d", chatId, msgId)
+
+	reactionUpdates.Lock()
+	defer reactionUpdates.Unlock()
+	_, queued := reactionUpdates.pending[key]
+	reactionUpdates.pending[key] = markup
+	if queued {
+		return
+	}
+
+	time.AfterFunc(reactionDebounce, func() {
+		reactionUpdates.Lock()
+		markup := reactionUpdates.pending[key]
+		delete(reactionUpdates.pending, key)
+		reactionUpdates.Unlock()
+
+		counts, err := db.GetVoteCounts(chatId, msgId)
+		if err != nil {
+			log.Printf("[reactions] GetVoteCounts: %v - Chat: %d, Msg: %d", err, chatId, msgId)
+			return
+		}
+
+		_, _, err = b.EditMessageReplyMarkup(&gotgbot.EditMessageReplyMarkupOpts{
+			ChatId:      chatId,
+			MessageId:   msgId,
+			ReplyMarkup: helpers.CountReactions(markup, buttons, counts),
+		})
+		if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+			log.Printf("[reactions] EditMessageReplyMarkup: %v - Chat: %d, Msg: %d", err, chatId, msgId)
+		}
+	})
+}
@@ -3,6 +3,9 @@ package modules
 import (
 	"AshokShau/channelManager/src/db"
 	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
+	"errors"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -12,144 +15,188 @@ import (
 )
 
 func repost(b *gotgbot.Bot, ctx *ext.Context) error {
-	msg := ctx.EffectiveMessage
+	msg, flags, errorMsg := helpers.ParseSendOptions(ctx.EffectiveMessage, 1)
 	if msg.Chat.Type != "private" {
 		return nil
 	}
+	if errorMsg != "" {
+		_, err := msg.Reply(b, errorMsg, helpers.Shtml())
+		return err
+	}
 
-	chatIds := isConnected(b, ctx, msg.From.Id)
+	chatIds := isConnected(b, ctx, msg.From.Id, onlyAdmins.CanPostMessages, onlyAdmins.CanDeleteMessages)
 	if chatIds == nil {
 		return nil
 	}
 
 	reply := msg.ReplyToMessage
 	if reply == nil {
-		_, _ = msg.Reply(b, "Please reply to a message to re-post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Please reply to a message to re-post."), helpers.Shtml())
 		return nil
 	}
 
-	args := ctx.Args()[1:]
+	args := strings.Fields(msg.Text)[1:]
 	if len(args) < 1 {
-		_, err := msg.Reply(b, "Please provide a post ID to re-post.\nUsage: <code>!repost post_id</code>", helpers.Shtml())
+		_, err := msg.Reply(b, tr(ctx, "Please provide a post ID to re-post.\nUsage: <code>!repost post_id</code>"), helpers.Shtml())
 		return err
 	}
 
 	post, err := db.GetPost(args[0])
 	if err != nil || post == nil {
-		_, _ = msg.Reply(b, "Post not found or an error occurred while retrieving the post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or an error occurred while retrieving the post."), helpers.Shtml())
 		return err
 	}
 
-	message, err := msg.Reply(b, "📤 Reposting post to connected chats...\nThis may take some time.", helpers.Shtml())
-	if err != nil {
-		return err
+	postText, dataType, fileId, buttons, errorMsg := helpers.GetMsgType(msg)
+	if dataType == -1 {
+		_, _ = msg.Reply(b, errorMsg, helpers.Shtml())
+		return nil
+	}
+	options := post.Options.Merge(flags.Overrides)
+	pin, comment := post.Pin, post.Comment
+	if flags.Pin != nil {
+		pin = flags.Pin
+	}
+	if flags.Comment != "" {
+		comment = flags.Comment
 	}
 
-	// Delete the old post
-	deletedCount := 0
-	for _, chat := range post.Chats {
-		_, err = b.DeleteMessage(chat.ChatId, chat.MsgId, nil)
+	// deliver reposts the checked post to the checked chats, also when a policy report is overridden later
+	lang := i18n.Lang(ctx.EffectiveUser)
+	deliver := func(b *gotgbot.Bot) error {
+		message, err := msg.Reply(b, i18n.T(lang, "📤 Reposting post to connected chats...\nThis may take some time."), helpers.Shtml())
 		if err != nil {
-			continue
+			return err
 		}
-		deletedCount++
-		time.Sleep(200 * time.Millisecond)
-	}
 
-	_ = db.RemovePost(args[0])
+		// Delete the old post
+		deletedCount := 0
+		for _, chat := range post.Chats {
+			_, err = b.DeleteMessage(chat.ChatId, chat.MsgId, nil)
+			if err != nil {
+				continue
+			}
+			emitDeleted(post.UserId, post.PostId, chat.ChatId, chat.MsgId)
+			deletedCount++
+			time.Sleep(200 * time.Millisecond)
+		}
 
-	// Send the new post
-	postId := helpers.GenerateUniqueString()
-	postText, dataType, fileId, buttons, errorMsg := helpers.GetMsgType(msg)
-	if dataType == -1 {
-		_, _, _ = message.EditText(b, errorMsg, nil)
-		return nil
-	}
+		_ = db.RemovePost(args[0])
 
-	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers.BuildKeyboard(buttons)}
-	if keyboard.InlineKeyboard == nil {
-		keyboard.InlineKeyboard = make([][]gotgbot.InlineKeyboardButton, 0)
-	}
+		// Send the new post
+		postId := helpers.GenerateUniqueString()
 
-	var successChats []int64
-	var failedChats []int64
-	userSettins := db.GetUserSettings(msg.From.Id)
-
-	for _, chatId := range chatIds {
-		message, err := b.CopyMessage(chatId, reply.Chat.Id, reply.MessageId, &gotgbot.CopyMessageOpts{
-			ParseMode:             "HTML",
-			ReplyMarkup:           &keyboard,
-			Caption:               &postText,
-			ProtectContent:        userSettins.Protect,
-			ShowCaptionAboveMedia: userSettins.CaptionAbove,
-			DisableNotification:   userSettins.NoNotif,
-		})
+		var successChats []int64
+		var failedChats []int64
+		var pinFailed []string
+		userSettins := db.GetUserSettings(msg.From.Id)
+		userSettins.Options = options
+		marks := make(watermarkCache)
 
-		if err != nil {
-			failedChats = append(failedChats, chatId)
-			continue
+		for _, chatId := range chatIds {
+			message, err := sendPostTo(b, nil, msg.From.Id, chatId, postId, dataType, postText, fileId, buttons, userSettins, marks)
+			if err != nil {
+				emitFailed(msg.From.Id, postId, chatId, err)
+				failedChats = append(failedChats, chatId)
+				continue
+			}
+
+			successChats = append(successChats, chatId)
+			_, _ = db.AddPost(postId, msg.From.Id, chatId, message.MessageId, dataType, fileId, buttons, postText)
+			emitSent(msg.From.Id, postId, message)
+			queueComment(b, chatId, message.MessageId, postId, comment)
+			if pin != nil {
+				if err := pinMessage(b, chatId, message.MessageId, pin); err != nil {
+					pinFailed = append(pinFailed, pinFailure(lang, chatId, err))
+				}
+			}
+			time.Sleep(100 * time.Millisecond)
 		}
 
-		successChats = append(successChats, chatId)
-		_, _ = db.AddPost(postId, msg.From.Id, chatId, message.MessageId, dataType, fileId, buttons, postText)
-		time.Sleep(100 * time.Millisecond)
-	}
+		if comment != "" && len(successChats) > 0 {
+			_ = db.SetPostComment(postId, comment)
+		}
+		if pin != nil && len(successChats) > 0 {
+			_ = db.SetPostPin(postId, pin)
+		}
+		if options != nil && len(successChats) > 0 {
+			_ = db.SetPostOptions(postId, options)
+		}
 
-	// Prepare summary
-	text := fmt.Sprintf("✅ Re-post initiated.\nOld Post Deleted from %d chats.\n", deletedCount)
-	text += fmt.Sprintf("New PostId: <code>%s</code>\n\n", postId)
+		// Prepare summary
+		text := i18n.T(lang, "✅ Re-post initiated.\nOld Post Deleted from %d chats.\n", deletedCount)
+		text += i18n.T(lang, "New PostId: <code>%s</code>\n\n", postId)
 
-	if len(successChats) > 0 {
-		text += "✅ Sent to the following chats:\n"
-		for _, chatId := range successChats {
-			messageLink := helpers.GetMessageLink(chatId, reply.MessageId)
-			text += fmt.Sprintf("- <code>%d</code> (<a href='%s'>View</a>)\n", chatId, messageLink)
+		if len(successChats) > 0 {
+			text += i18n.T(lang, "✅ Sent to the following chats:\n")
+			for _, chatId := range successChats {
+				messageLink := helpers.GetMessageLink(chatId, reply.MessageId)
+				text += i18n.T(lang, "- <code>%d</code> (<a href='%s'>View</a>)\n", chatId, messageLink)
+			}
+			text += "\n"
 		}
-		text += "\n"
-	}
 
-	if len(failedChats) > 0 {
-		text += "❌ Failed to send to the following chats:\n"
-		for _, chatId := range failedChats {
-			text += fmt.Sprintf("- <code>%d</code>\n", chatId)
+		if len(failedChats) > 0 {
+			text += i18n.T(lang, "❌ Failed to send to the following chats:\n")
+			for _, chatId := range failedChats {
+				text += fmt.Sprintf("- <code>%d</code>\n", chatId)
+			}
+			text += "\n"
 		}
-		text += "\n"
+
+		text += pinSummary(lang, pinFailed)
+		text += i18n.T(lang, "If you want to delete this post and send another one, use <code>!repost %s</code>", postId)
+		_, _, _ = message.EditText(b, text, &gotgbot.EditMessageTextOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: helpers.PostButton(lang, postId),
+		})
+		return nil
 	}
 
-	text += fmt.Sprintf("If you want to delete this post and send another one, use <code>!repost %s</code>", postId)
-	_, _, _ = message.EditText(b, text, &gotgbot.EditMessageTextOpts{
-		ParseMode:   "HTML",
-		ReplyMarkup: helpers.PostButton(postId),
-	})
-	return nil
+	if !policyGate(b, ctx, msg.From.Id, chatIds, postText, buttons, deliver) {
+		return nil
+	}
+	return deliver(b)
 }
 
 func editPost(b *gotgbot.Bot, ctx *ext.Context) error {
-	msg := ctx.EffectiveMessage
+	msg, flags, errorMsg := helpers.ParseSendOptions(ctx.EffectiveMessage, 1)
 	if msg.Chat.Type != "private" {
 		return nil
 	}
+	if errorMsg != "" {
+		_, err := msg.Reply(b, errorMsg, helpers.Shtml())
+		return err
+	}
+	if flags.Pin != nil {
+		_, err := msg.Reply(b, tr(ctx, "Edits don't pin posts, use <code>!pin PostId</code> to pin a sent post."), helpers.Shtml())
+		return err
+	}
+	if flags.Comment != "" {
+		_, err := msg.Reply(b, tr(ctx, "Use <code>!comment PostId text</code> to change the comment of a sent post."), helpers.Shtml())
+		return err
+	}
 
-	chatIds := isConnected(b, ctx, msg.From.Id)
+	chatIds := isConnected(b, ctx, msg.From.Id, onlyAdmins.CanEditMessages)
 	if chatIds == nil {
 		return nil
 	}
 
-	args := ctx.Args()[1:]
+	args := strings.Fields(msg.Text)[1:]
 	if len(args) < 1 {
-		_, err := msg.Reply(b, "Please provide a post ID to edit.\nUsage: <code>!edit post_id</code>", helpers.Shtml())
+		_, err := msg.Reply(b, tr(ctx, "Please provide a post ID to edit.\nUsage: <code>!edit post_id</code>"), helpers.Shtml())
 		return err
 	}
 
 	post, err := db.GetPost(args[0])
 	if err != nil || post == nil {
-		_, _ = msg.Reply(b, "Post not found or an error occurred while retrieving the post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or an error occurred while retrieving the post."), helpers.Shtml())
 		return err
 	}
 
 	reply := msg.ReplyToMessage
 	if reply == nil {
-		_, _ = msg.Reply(b, "To edit a post, you need to reply to a message that you want to share with all connected chats.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "To edit a post, you need to reply to a message that you want to share with all connected chats."), helpers.Shtml())
 		return nil
 	}
 
@@ -160,260 +207,100 @@ func editPost(b *gotgbot.Bot, ctx *ext.Context) error {
 		return nil
 	}
 
-	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers.BuildKeyboard(buttons)}
-	if keyboard.InlineKeyboard == nil {
-		keyboard.InlineKeyboard = make([][]gotgbot.InlineKeyboardButton, 0)
-	}
-	message, err := msg.Reply(b, "Please wait while the post is being edited...", helpers.Shtml())
-	if err != nil {
-		return err
+	var postChats []int64
+	for _, chat := range post.Chats {
+		if !chat.Comment {
+			postChats = append(postChats, chat.ChatId)
+		}
 	}
+	// deliver edits the checked post in the checked chats, also when a policy report is overridden later
+	lang := i18n.Lang(ctx.EffectiveUser)
+	deliver := func(b *gotgbot.Bot) error {
+		message, err := msg.Reply(b, i18n.T(lang, "Please wait while the post is being edited..."), helpers.Shtml())
+		if err != nil {
+			return err
+		}
 
-	userSetting := db.GetUserSettings(msg.From.Id)
-	media := gotgbot.InputMedia(nil)
-	mediaText := postText
-	if mediaText == "" {
-		mediaText = "."
-	}
+		options := post.Options.Merge(flags.Overrides)
+		userSetting := db.GetUserSettings(msg.From.Id)
+		userSetting.Options = options
 
-	switch dataType {
-	case db.PHOTO:
-		media = gotgbot.InputMediaPhoto{
-			Media:                 gotgbot.InputFileByID(fileId),
-			Caption:               mediaText,
-			ParseMode:             "HTML",
-			ShowCaptionAboveMedia: userSetting.CaptionAbove,
-			HasSpoiler:            userSetting.Spoiler,
-		}
-	case db.GIF:
-		media = gotgbot.InputMediaAnimation{
-			Media:                 gotgbot.InputFileByID(fileId),
-			Caption:               mediaText,
-			ParseMode:             "HTML",
-			ShowCaptionAboveMedia: userSetting.CaptionAbove,
-			HasSpoiler:            userSetting.Spoiler,
-		}
-	case db.DOCUMENT:
-		media = gotgbot.InputMediaDocument{
-			Media:     gotgbot.InputFileByID(fileId),
-			Caption:   mediaText,
-			ParseMode: "HTML",
-		}
-	case db.AUDIO:
-		media = gotgbot.InputMediaAudio{
-			Media:     gotgbot.InputFileByID(fileId),
-			Caption:   mediaText,
-			ParseMode: "HTML",
-		}
-	case db.VIDEO:
-		media = gotgbot.InputMediaVideo{
-			Media:                 gotgbot.InputFileByID(fileId),
-			Caption:               mediaText,
-			ParseMode:             "HTML",
-			ShowCaptionAboveMedia: userSetting.CaptionAbove,
-			HasSpoiler:            userSetting.Spoiler,
-		}
-	case db.VOICE:
-		media = gotgbot.InputMediaAudio{
-			Media:     gotgbot.InputFileByID(fileId),
-			Caption:   mediaText,
-			ParseMode: "HTML",
-		}
-	default:
-		media = nil
-	}
+		var successChats []string
+		var failedChats []string
 
-	var successChats []string
-	var failedChats []string
+		oldDataType := post.MsgType
 
-	oldDataType := post.MsgType
+		newPostId := helpers.GenerateUniqueString()
+		marks := make(watermarkCache)
 
-	newPostId := helpers.GenerateUniqueString()
+		for _, chat := range post.Chats {
+			chatId := chat.ChatId
+			msgId := chat.MsgId
 
-	for _, chat := range post.Chats {
-		chatId := chat.ChatId
-		msgId := chat.MsgId
-
-		switch oldDataType {
-		case db.TEXT:
-			if fileId != "" {
-				if media == nil {
-					_, _, _ = message.EditText(b, "Something went wrong. Please try again later. or read help menu", nil)
-					return nil
-				}
-				_, _, err := b.EditMessageMedia(
-					media,
-					&gotgbot.EditMessageMediaOpts{
-						ChatId:      chatId,
-						MessageId:   msgId,
-						ReplyMarkup: keyboard,
-					})
-				if err != nil {
-					failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-					continue
-				} else {
-					successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-					_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
-				}
-			} else {
-				_, _, err := b.EditMessageText(mediaText, &gotgbot.EditMessageTextOpts{
-					ChatId:             chatId,
-					MessageId:          msgId,
-					ParseMode:          "HTML",
-					ReplyMarkup:        keyboard,
-					LinkPreviewOptions: &gotgbot.LinkPreviewOptions{IsDisabled: userSetting.WebPreview},
-				})
-				if err != nil {
-					failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-					continue
-				} else {
-					successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-					_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
-				}
-			}
-		case db.PHOTO:
-			if fileId != "" {
-				_, _, err := b.EditMessageMedia(
-					media,
-					&gotgbot.EditMessageMediaOpts{
-						ChatId:      chatId,
-						MessageId:   msgId,
-						ReplyMarkup: keyboard,
-					})
-				if err != nil {
-					failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-					continue
-				} else {
-					successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-					_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
-				}
-			} else {
-				_, _, err := b.EditMessageCaption(&gotgbot.EditMessageCaptionOpts{
-					ChatId:      chatId,
-					MessageId:   msgId,
-					Caption:     mediaText,
-					ParseMode:   "HTML",
-					ReplyMarkup: keyboard,
-				})
-				if err != nil {
-					failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-					continue
-				} else {
-					successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-					_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
-				}
-			}
-		case db.STICKER:
-			_, _, err := b.EditMessageReplyMarkup(&gotgbot.EditMessageReplyMarkupOpts{
-				ChatId:      chatId,
-				MessageId:   msgId,
-				ReplyMarkup: keyboard,
-			})
-			if err != nil {
-				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
+			// Comments in discussion groups keep their text, they are edited with !comment
+			if chat.Comment {
+				_ = db.AddPostComment(newPostId, chatId, msgId)
 				continue
-			} else {
-				successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-				_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
 			}
-		case db.AUDIO:
-			_, _, err := b.EditMessageMedia(
-				media,
-				&gotgbot.EditMessageMediaOpts{
-					ChatId:      chatId,
-					MessageId:   msgId,
-					ReplyMarkup: keyboard,
-				})
-			if err != nil {
+
+			chatSetting := db.GetChatSettings(msg.From.Id, chatId)
+			err = editPostMessage(b, chatSetting, msgId, oldDataType, newPostId, dataType, postText, fileId, buttons, userSetting, marks)
+			switch {
+			case errors.Is(err, errNoEditMedia):
+				_, _, _ = message.EditText(b, i18n.T(lang, "Something went wrong. Please try again later. or read help menu"), nil)
+				return nil
+			case errors.Is(err, errUnknownPostType):
+				_, _, _ = message.EditText(b, i18n.T(lang, "Unknown data type."), &gotgbot.EditMessageTextOpts{ParseMode: "HTML"})
+				return nil
+			case err != nil:
+				log.Printf("editPost: Error editing message in ChatID %d: %v", chatId, err)
 				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
 				continue
-			} else {
-				successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-				_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
 			}
-		case db.VIDEO:
-			_, _, err := b.EditMessageMedia(
-				media,
-				&gotgbot.EditMessageMediaOpts{
-					ChatId:      chatId,
-					MessageId:   msgId,
-					ReplyMarkup: keyboard,
-				})
-			if err != nil {
-				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-				continue
-			} else {
-				successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-				_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
+
+			successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
+			_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, buttons, postText)
+		}
+
+		var text string
+		// Send failed message
+		if len(failedChats) > 0 {
+			text += i18n.T(lang, "Failed to re-post to the following chats: %s", strings.Join(failedChats, ", "))
+		}
+
+		if len(successChats) > 0 {
+			text += i18n.T(lang, "Re-posted to the following chats: %s", strings.Join(successChats, ", "))
+		}
+		if post.Comment != "" {
+			_ = db.SetPostComment(newPostId, post.Comment)
+		}
+		if len(successChats) > 0 {
+			if post.Pin != nil {
+				_ = db.SetPostPin(newPostId, post.Pin)
 			}
-		case db.VOICE:
-			_, _, err := b.EditMessageMedia(
-				media,
-				&gotgbot.EditMessageMediaOpts{
-					ChatId:      chatId,
-					MessageId:   msgId,
-					ReplyMarkup: keyboard,
-				})
-			if err != nil {
-				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-				continue
-			} else {
-				successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-				_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
+			if post.Source != nil {
+				_ = db.SetPostSource(newPostId, post.Source.ChatId, post.Source.MsgId)
 			}
-		case db.GIF:
-			_, _, err := b.EditMessageMedia(
-				media,
-				&gotgbot.EditMessageMediaOpts{
-					ChatId:      chatId,
-					MessageId:   msgId,
-					ReplyMarkup: keyboard,
-				})
-			if err != nil {
-				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-				continue
-			} else {
-				successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-				_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
+			if options != nil {
+				_ = db.SetPostOptions(newPostId, options)
 			}
-		case db.DOCUMENT:
-			_, _, err := b.EditMessageMedia(
-				media,
-				&gotgbot.EditMessageMediaOpts{
-					ChatId:      chatId,
-					MessageId:   msgId,
-					ReplyMarkup: keyboard,
-				})
+			_ = db.AddPostVersions(newPostId, append(post.Versions, post.Version(time.Now().Unix()))...)
+		}
+
+		go func() {
+			err := db.RemovePost(post.PostId)
 			if err != nil {
-				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-				continue
-			} else {
-				successChats = append(successChats, fmt.Sprintf("<code>%d</code>", chatId))
-				_, _ = db.AddPost(newPostId, msg.From.Id, chatId, msgId, dataType, fileId, post.Buttons, post.FilterReply)
+				log.Printf("repost: Error removing post: %v", err)
 			}
-		default:
-			_, _, _ = message.EditText(b, "Unknown data type.", &gotgbot.EditMessageTextOpts{ParseMode: "HTML"})
-			return nil
-		}
-	}
+		}()
 
-	var text string
-	// Send failed message
-	if len(failedChats) > 0 {
-		text += fmt.Sprintf("Failed to re-post to the following chats: %s", strings.Join(failedChats, ", "))
+		text += i18n.T(lang, "\n\nNewPost ID: <code>%s</code>", newPostId)
+		_, _, err = message.EditText(b, text, &gotgbot.EditMessageTextOpts{ParseMode: "HTML", ReplyMarkup: helpers.PostButton(lang, newPostId)})
+		return err
 	}
 
-	if len(successChats) > 0 {
-		text += fmt.Sprintf("Re-posted to the following chats: %s", strings.Join(successChats, ", "))
+	if !policyGate(b, ctx, msg.From.Id, postChats, postText, buttons, deliver) {
+		return nil
 	}
-	go func() {
-		err := db.RemovePost(post.PostId)
-		if err != nil {
-			log.Printf("repost: Error removing post: %v", err)
-		}
-	}()
-
-	text += fmt.Sprintf("\n\nNewPost ID: <code>%s</code>", newPostId)
-	_, _, err = message.EditText(b, text, &gotgbot.EditMessageTextOpts{ParseMode: "HTML", ReplyMarkup: helpers.PostButton(newPostId)})
-	return err
+	return deliver(b)
 }
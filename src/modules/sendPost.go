@@ -3,6 +3,8 @@ package modules
 import (
 	"AshokShau/channelManager/src/db"
 	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -15,20 +17,20 @@ import (
 
 func delAllPosts(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
-	chatIds := isConnected(b, ctx, msg.From.Id)
+	chatIds := isConnected(b, ctx, msg.From.Id, onlyAdmins.CanDeleteMessages)
 	if chatIds == nil {
 		return nil
 	}
 	args := ctx.Args()[1:]
 	if len(args) < 1 {
-		_, err := msg.Reply(b, "Please provide a PostId to delete all posts .", helpers.Shtml())
+		_, err := msg.Reply(b, tr(ctx, "Please provide a PostId to delete all posts ."), helpers.Shtml())
 		return err
 	}
 
 	postId := args[0]
 	post, err := db.GetPost(postId)
 	if err != nil || post == nil {
-		_, _ = msg.Reply(b, "Post not found or error retrieving post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or error retrieving post."), helpers.Shtml())
 		return err
 	}
 
@@ -38,46 +40,56 @@ func delAllPosts(b *gotgbot.Bot, ctx *ext.Context) error {
 			log.Printf("deleteAllPost: Error deleting message from ChatID %d: %v", chat.ChatId, err)
 			continue
 		}
+		emitDeleted(post.UserId, postId, chat.ChatId, chat.MsgId)
 		time.Sleep(100 * time.Millisecond)
 	}
 	_ = db.RemovePost(postId)
-	_, _ = msg.Reply(b, "All posts deleted.", helpers.Shtml())
+	_, _ = msg.Reply(b, tr(ctx, "All posts deleted."), helpers.Shtml())
 	return nil
 
 }
 
 func deletePost(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
-	chatIds := isConnected(b, ctx, msg.From.Id)
+	chatIds := isConnected(b, ctx, msg.From.Id, onlyAdmins.CanDeleteMessages)
 	if chatIds == nil {
 		return nil
 	}
 
 	args := ctx.Args()[1:]
 	if len(args) < 2 {
-		_, err := msg.Reply(b, "Please provide both a channel ID and a message ID to delete.\nUsage: <code>!delete channel_id msg_id</code>", helpers.Shtml())
+		_, err := msg.Reply(b, tr(ctx, "Please provide both a channel ID and a message ID to delete.\nUsage: <code>!delete channel_id msg_id</code>"), helpers.Shtml())
 		return err
 	}
 
-	_, err := b.DeleteMessage(helpers.ToInt64(args[0]), helpers.ToInt64(args[1]), nil)
+	chatId, msgId := helpers.ToInt64(args[0]), helpers.ToInt64(args[1])
+	_, err := b.DeleteMessage(chatId, msgId, nil)
 	if err != nil {
-		_, _ = msg.Reply(b, "Error deleting message.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Error deleting message."), helpers.Shtml())
 		return err
 	}
 
-	_, _ = msg.Reply(b, "Message deleted.", helpers.Shtml())
+	if post, _ := db.GetPostByMessage(chatId, msgId); post != nil {
+		emitDeleted(post.UserId, post.PostId, chatId, msgId)
+	}
+
+	_, _ = msg.Reply(b, tr(ctx, "Message deleted."), helpers.Shtml())
 	return nil
 }
 
 func createPost(b *gotgbot.Bot, ctx *ext.Context) error {
-	msg := ctx.EffectiveMessage
+	msg, flags, errorMsg := helpers.ParseSendOptions(ctx.EffectiveMessage, 0)
 	if msg.Chat.Type != "private" {
 		return nil
 	}
+	if errorMsg != "" {
+		_, err := msg.Reply(b, errorMsg, helpers.Shtml())
+		return err
+	}
 
-	args := ctx.Args()[1:]
+	args := strings.Fields(msg.Text)[1:]
 	if len(args) == 0 && msg.ReplyToMessage == nil {
-		_, _ = msg.Reply(b, "Please provide a message to send.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Please provide a message to send."), helpers.Shtml())
 		return nil
 	}
 
@@ -90,7 +102,7 @@ func createPost(b *gotgbot.Bot, ctx *ext.Context) error {
 	postId := helpers.GenerateUniqueString()
 	buttonNoText := helpers.RevertButtons(buttons)
 	if buttonNoText == "" {
-		buttonNoText = "No buttons"
+		buttonNoText = tr(ctx, "No buttons")
 	}
 
 	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers.BuildKeyboard(buttons)}
@@ -99,26 +111,36 @@ func createPost(b *gotgbot.Bot, ctx *ext.Context) error {
 	}
 
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []gotgbot.InlineKeyboardButton{
-		{Text: "Send Post to all chats", CallbackData: fmt.Sprintf("send.%s", postId)},
-		{Text: "Copy Button Text", CopyText: &gotgbot.CopyTextButton{Text: buttonNoText}},
+		{Text: tr(ctx, "Send Post to all chats"), CallbackData: fmt.Sprintf("send.%s", postId)},
+		{Text: tr(ctx, "Copy Button Text"), CopyText: &gotgbot.CopyTextButton{Text: buttonNoText}},
 	})
 
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []gotgbot.InlineKeyboardButton{
-		{Text: "Share Vai Inline", CopyText: &gotgbot.CopyTextButton{Text: fmt.Sprintf("@%s %s", b.Username, postId)}},
+		{Text: tr(ctx, "Share Vai Inline"), CopyText: &gotgbot.CopyTextButton{Text: fmt.Sprintf("@%s %s", b.Username, postId)}},
 	})
 
 	userSettings := db.GetUserSettings(msg.From.Id)
+	userSettings.Options = flags.Overrides
 	send, err := helpers.PostEnumFuncMap[dataType](b, ctx, ctx.EffectiveChat.Id, text, fileId, &keyboard, userSettings)
 	if err != nil {
-		_, _ = msg.Reply(b, "Error creating Post.\n\n<code>"+err.Error()+"</code>", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Error creating Post.\n\n<code>")+err.Error()+"</code>", helpers.Shtml())
 		return fmt.Errorf("createPost: error in sending message: %v", err)
 	}
 
 	_, err = db.AddPost(postId, msg.From.Id, b.Id, send.MessageId, dataType, fileId, buttons, text)
 	if err != nil {
-		_, _ = msg.Reply(b, "Error creating Post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Error creating Post."), helpers.Shtml())
 		return err
 	}
+	if flags.Overrides != nil {
+		_ = db.SetPostOptions(postId, flags.Overrides)
+	}
+	if flags.Pin != nil {
+		_ = db.SetPostPin(postId, flags.Pin)
+	}
+	if flags.Comment != "" {
+		_ = db.SetPostComment(postId, flags.Comment)
+	}
 	return ext.EndGroups
 }
 
@@ -126,14 +148,14 @@ func getPost(b *gotgbot.Bot, ctx *ext.Context) error {
 	msg := ctx.EffectiveMessage
 	args := ctx.Args()[1:]
 	if len(args) < 1 {
-		_, _ = msg.Reply(b, "Please provide a PostId to share.\nUsage: <code>!share PostId</code>", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Please provide a PostId to share.\nUsage: <code>!share PostId</code>"), helpers.Shtml())
 		return nil
 	}
 
 	postId := args[0]
 	post, err := db.GetPost(postId)
 	if err != nil || post == nil {
-		_, _ = msg.Reply(b, "Post not found or error retrieving post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Post not found or error retrieving post."), helpers.Shtml())
 		return err
 	}
 
@@ -142,17 +164,18 @@ func getPost(b *gotgbot.Bot, ctx *ext.Context) error {
 		keyboard.InlineKeyboard = make([][]gotgbot.InlineKeyboardButton, 0)
 	}
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []gotgbot.InlineKeyboardButton{
-		{Text: "Send Post to all chats", CallbackData: fmt.Sprintf("send.%s", postId)},
+		{Text: tr(ctx, "Send Post to all chats"), CallbackData: fmt.Sprintf("send.%s", postId)},
 	})
 
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []gotgbot.InlineKeyboardButton{
-		{Text: "Share Vai Inline", CopyText: &gotgbot.CopyTextButton{Text: fmt.Sprintf("@%s %s", b.Username, postId)}},
+		{Text: tr(ctx, "Share Vai Inline"), CopyText: &gotgbot.CopyTextButton{Text: fmt.Sprintf("@%s %s", b.Username, postId)}},
 	})
 
 	userSettings := db.GetUserSettings(msg.From.Id)
+	userSettings.Options = post.Options
 	_, err = helpers.PostEnumFuncMap[post.MsgType](b, ctx, ctx.EffectiveChat.Id, post.FilterReply, post.FileID, &keyboard, userSettings)
 	if err != nil {
-		_, _ = msg.Reply(b, "Error sending post.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Error sending post."), helpers.Shtml())
 		return fmt.Errorf("getPost: error in sending message: %v", err)
 	}
 
@@ -160,34 +183,52 @@ func getPost(b *gotgbot.Bot, ctx *ext.Context) error {
 }
 
 func sendPost(b *gotgbot.Bot, ctx *ext.Context) error {
-	msg := ctx.EffectiveMessage
-	var successChats, failedChats []string
+	msg, flags, errorMsg := helpers.ParseSendOptions(ctx.EffectiveMessage, 0)
+	var successChats, failedChats, pinFailed []string
+	if errorMsg != "" {
+		_, err := msg.Reply(b, errorMsg, helpers.Shtml())
+		return err
+	}
 
-	chatIds := isConnected(b, ctx, msg.From.Id)
+	chatIds := isConnected(b, ctx, msg.From.Id, onlyAdmins.CanPostMessages)
 	if chatIds == nil {
-		_, _ = msg.Reply(b, "You are not connected to any chats. Please connect to at least one chat to send posts.", helpers.Shtml())
 		return nil
 	}
 
 	reply := msg.ReplyToMessage
 	if reply == nil {
-		_, _ = msg.Reply(b, "To send a post, you need to reply to a message that you want to share with all connected chats.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "To send a post, you need to reply to a message that you want to share with all connected chats."), helpers.Shtml())
 		return nil
 	}
 
-	message, err := msg.Reply(b, "📤 Sending post to connected chats...\nThis may take some time.", helpers.Shtml())
-	if err != nil {
+	postId := helpers.GenerateUniqueString()
+	userSettings := db.GetUserSettings(msg.From.Id)
+	userSettings.Options = flags.Overrides
+
+	// Chats can override the forward tag, the message is forwarded to chats that have it on and copied to the others
+	chatSettings := make(map[int64]*db.UserSettings, len(chatIds))
+	forwardAll := true
+	for _, chatId := range chatIds {
+		chatSettings[chatId] = db.EffectiveSettings(userSettings, chatId)
+		forwardAll = forwardAll && chatSettings[chatId].ForwardTag
+	}
+
+	postText, dataType, fileId, buttons, errorMsg := helpers.GetMsgType(msg)
+	if dataType == -1 && !forwardAll {
+		_, err := msg.Reply(b, errorMsg, helpers.Shtml())
 		return err
 	}
 
-	postId := helpers.GenerateUniqueString()
-	userSettings := db.GetUserSettings(msg.From.Id)
-	forwardTag := userSettings.ForwardTag
+	// deliver sends the checked post to the checked chats, also when a policy report is overridden later
+	lang := i18n.Lang(ctx.EffectiveUser)
+	deliver := func(b *gotgbot.Bot) error {
+		message, err := msg.Reply(b, i18n.T(lang, "📤 Sending post to connected chats...\nThis may take some time."), helpers.Shtml())
+		if err != nil {
+			return err
+		}
 
-	if forwardTag {
-		postText, dataType, fileId, buttons, _ := helpers.GetMsgType(msg)
 		successCount, failedCount := 0, 0
-
+		marks := make(watermarkCache)
 		for i, chatId := range chatIds {
 			if (successCount+failedCount)%23 == 0 && i > 0 {
 				// if send count is 23, sleep for 1 minute
@@ -196,80 +237,70 @@ func sendPost(b *gotgbot.Bot, ctx *ext.Context) error {
 				successCount, failedCount = 0, 0
 			}
 
-			message, err := b.ForwardMessage(chatId, msg.Chat.Id, reply.MessageId, &gotgbot.ForwardMessageOpts{
-				DisableNotification: userSettings.NoNotif,
-				ProtectContent:      userSettings.Protect,
-			})
-
+			var message *gotgbot.Message
+			var err error
+			if chatSetting := chatSettings[chatId]; chatSetting.ForwardTag {
+				message, err = b.ForwardMessage(chatId, msg.Chat.Id, reply.MessageId, &gotgbot.ForwardMessageOpts{
+					DisableNotification: chatSetting.NoNotif,
+					ProtectContent:      chatSetting.Protect,
+				})
+			} else {
+				message, err = sendPostTo(b, nil, msg.From.Id, chatId, postId, dataType, postText, fileId, buttons, userSettings, marks)
+			}
 			if err != nil {
-				log.Printf("Failed to forward message to chatId %d: %v", chatId, err)
+				log.Printf("Failed to send post to chatId %d: %v", chatId, err)
+				emitFailed(msg.From.Id, postId, chatId, err)
+				failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
 				failedCount++
-				continue
+			} else {
+				// Include the !del command for easy deletion
+				successChats = append(successChats, fmt.Sprintf(
+					"<a href='%s'>%d</a>\n(<code>!del %d %d</code>)",
+					message.GetLink(), chatId, chatId, message.MessageId,
+				))
+				_, _ = db.AddPost(postId, msg.From.Id, chatId, message.MessageId, dataType, fileId, buttons, postText)
+				emitSent(msg.From.Id, postId, message)
+				queueComment(b, chatId, message.MessageId, postId, flags.Comment)
+				if flags.Pin != nil {
+					if err := pinMessage(b, chatId, message.MessageId, flags.Pin); err != nil {
+						pinFailed = append(pinFailed, pinFailure(lang, chatId, err))
+					}
+				}
+				successCount++
 			}
 
-			_, _ = db.AddPost(postId, msg.From.Id, chatId, message.MessageId, dataType, fileId, buttons, postText)
-			successCount++
 			time.Sleep(50 * time.Millisecond)
 		}
 
-		_, _, err = message.EditText(b, fmt.Sprintf("Message forwarded to all connected chats.\n\n<b>PostId:</b> <code>%s</code>", postId), &gotgbot.EditMessageTextOpts{
-			ParseMode:   "HTML",
-			ReplyMarkup: helpers.PostButton(postId),
-		})
+		if flags.Overrides != nil && len(successChats) > 0 {
+			_ = db.SetPostOptions(postId, flags.Overrides)
+		}
+		if flags.Pin != nil && len(successChats) > 0 {
+			_ = db.SetPostPin(postId, flags.Pin)
+		}
+		if flags.Comment != "" && len(successChats) > 0 {
+			_ = db.SetPostComment(postId, flags.Comment)
+		}
 
-		return err
-	}
+		responseText := i18n.T(lang,
+			"<b>Post Result Summary:</b>\n\n✅ <b>Successfully sent to:</b>\n%s\n\n❌ <b>Failed to send to:</b>\n%s\n",
+			strings.Join(successChats, "\n"),
+			strings.Join(failedChats, "\n"),
+		)
+		responseText += pinSummary(lang, pinFailed)
+		responseText += i18n.T(lang, "<b>PostId:</b> <code>%s</code>", postId)
 
-	postText, dataType, fileId, buttons, errorMsg := helpers.GetMsgType(msg)
-	if dataType == -1 {
-		_, _, err = message.EditText(b, errorMsg, &gotgbot.EditMessageTextOpts{
-			ParseMode: "HTML",
+		_, _, err = message.EditText(b, responseText, &gotgbot.EditMessageTextOpts{
+			ParseMode:   "HTML",
+			ReplyMarkup: helpers.PostButton(lang, postId),
 		})
 		return err
 	}
 
-	keyboard := gotgbot.InlineKeyboardMarkup{InlineKeyboard: helpers.BuildKeyboard(buttons)}
-	if len(keyboard.InlineKeyboard) == 0 {
-		keyboard.InlineKeyboard = [][]gotgbot.InlineKeyboardButton{}
-	}
-
-	successCount, failedCount := 0, 0
-	for i, chatId := range chatIds {
-		if (successCount+failedCount)%23 == 0 && i > 0 {
-			time.Sleep(1 * time.Minute)
-			successCount, failedCount = 0, 0
-		}
-
-		message, err := helpers.PostEnumFuncMap[dataType](b, ctx, chatId, postText, fileId, &keyboard, userSettings)
-		if err != nil {
-			log.Printf("Failed to send post to chatId %d: %v", chatId, err)
-			failedChats = append(failedChats, fmt.Sprintf("<code>%d</code>", chatId))
-			failedCount++
-		} else {
-			// Include the !del command for easy deletion
-			successChats = append(successChats, fmt.Sprintf(
-				"<a href='%s'>%d</a>\n(<code>!del %d %d</code>)",
-				message.GetLink(), chatId, chatId, message.MessageId,
-			))
-			_, _ = db.AddPost(postId, msg.From.Id, chatId, message.MessageId, dataType, fileId, buttons, postText)
-			successCount++
-		}
-
-		time.Sleep(50 * time.Millisecond)
+	if !policyGate(b, ctx, msg.From.Id, chatIds, postText, buttons, deliver) {
+		return nil
 	}
-
-	responseText := fmt.Sprintf(
-		"<b>Post Result Summary:</b>\n\n✅ <b>Successfully sent to:</b>\n%s\n\n❌ <b>Failed to send to:</b>\n%s\n<b>PostId:</b> <code>%s</code>",
-		strings.Join(successChats, "\n"),
-		strings.Join(failedChats, "\n"),
-		postId,
-	)
-
-	_, _, err = message.EditText(b, responseText, &gotgbot.EditMessageTextOpts{
-		ParseMode:   "HTML",
-		ReplyMarkup: helpers.PostButton(postId),
-	})
-	return err
+	return deliver(b)
 }
 
 // sendEmptyQueryResponse sends a response for an empty inline query.
@@ -278,7 +309,7 @@ func sendEmptyQueryResponse(bot *gotgbot.Bot, ctx *ext.Context) error {
 		IsPersonal: true,
 		CacheTime:  10,
 		Button: &gotgbot.InlineQueryResultsButton{
-			Text:           "Give me postId !",
+			Text:           tr(ctx, "Give me postId !"),
 			StartParameter: "start",
 		},
 	})
@@ -286,18 +317,18 @@ func sendEmptyQueryResponse(bot *gotgbot.Bot, ctx *ext.Context) error {
 }
 
 // noResultsArticle creates an inline query result indicating no results were found.
-func noResultsArticle(query string) gotgbot.InlineQueryResult {
+func noResultsArticle(lang, query string) gotgbot.InlineQueryResult {
 	return gotgbot.InlineQueryResultArticle{
 		Id:    strconv.Itoa(rand.Intn(100000)),
-		Title: "Post not found or error retrieving post.",
+		Title: i18n.T(lang, "Post not found or error retrieving post."),
 		InputMessageContent: gotgbot.InputTextMessageContent{
-			MessageText: fmt.Sprintf("<i>👋 Sorry, I couldn't find any results for '%s'!</i>", query),
+			MessageText: i18n.T(lang, "<i>👋 Sorry, I couldn't find any results for '%s'!</i>", query),
 			ParseMode:   gotgbot.ParseModeHTML,
 		},
-		Description: "No results found for your query.",
+		Description: i18n.T(lang, "No results found for your query."),
 		ReplyMarkup: &gotgbot.InlineKeyboardMarkup{
 			InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
-				{{Text: "Search Again", SwitchInlineQueryCurrentChat: &query}},
+				{{Text: i18n.T(lang, "Search Again"), SwitchInlineQueryCurrentChat: &query}},
 			},
 		},
 	}
@@ -317,7 +348,7 @@ func inlineSharePost(b *gotgbot.Bot, ctx *ext.Context) error {
 	// Retrieve the post from the database
 	post, err := db.GetPost(postId)
 	if err != nil || post == nil {
-		_, _ = ctx.InlineQuery.Answer(b, []gotgbot.InlineQueryResult{noResultsArticle(query)}, &gotgbot.AnswerInlineQueryOpts{
+		_, _ = ctx.InlineQuery.Answer(b, []gotgbot.InlineQueryResult{noResultsArticle(i18n.Lang(ctx.EffectiveUser), query)}, &gotgbot.AnswerInlineQueryOpts{
 			IsPersonal: true,
 			CacheTime:  500,
 		})
@@ -340,7 +371,7 @@ func inlineSharePost(b *gotgbot.Bot, ctx *ext.Context) error {
 	case db.TEXT:
 		results = append(results, gotgbot.InlineQueryResultArticle{
 			Id:    resultId,
-			Title: "Text Post",
+			Title: tr(ctx, "Text Post"),
 			InputMessageContent: gotgbot.InputTextMessageContent{
 				MessageText: postText,
 				ParseMode:   gotgbot.ParseModeHTML,
@@ -374,7 +405,7 @@ func inlineSharePost(b *gotgbot.Bot, ctx *ext.Context) error {
 	case db.DOCUMENT:
 		results = append(results, gotgbot.InlineQueryResultCachedDocument{
 			Id:             resultId,
-			Title:          "Document",
+			Title:          tr(ctx, "Document"),
 			DocumentFileId: fileId,
 			Caption:        postText,
 			ParseMode:      gotgbot.ParseModeHTML,
@@ -416,7 +447,7 @@ func inlineSharePost(b *gotgbot.Bot, ctx *ext.Context) error {
 			ReplyMarkup: &keyboard,
 		})
 	default:
-		results = append(results, noResultsArticle(postId))
+		results = append(results, noResultsArticle(i18n.Lang(ctx.EffectiveUser), postId))
 	}
 
 	// Send the results
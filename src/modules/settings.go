@@ -3,10 +3,15 @@ package modules
 import (
 	"AshokShau/channelManager/src/db"
 	"AshokShau/channelManager/src/modules/utils/helpers"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"AshokShau/channelManager/src/modules/utils/onlyAdmins"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func updateUserSettingHandler(b *gotgbot.Bot, ctx *ext.Context, settingName string, updateFunc func(int64, bool)) error {
@@ -34,14 +39,16 @@ func updateUserSettingHandler(b *gotgbot.Bot, ctx *ext.Context, settingName stri
 		currentSetting = getUser.WebPreview
 	case "CaptionAbove":
 		currentSetting = getUser.CaptionAbove
+	case "NoTracking":
+		currentSetting = getUser.NoTracking
 	default:
-		_, _ = msg.Reply(b, "Invalid setting specified.", helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Invalid setting specified."), helpers.Shtml())
 		return nil
 	}
 
 	// If no arguments, show current status
 	if len(args) < 1 {
-		text := fmt.Sprintf("Please provide args <code> y/yes/true/on/n/no/false/off </code> to update the %s setting.\nUsage: <code>!%s args</code>\n\nCurrent setting: %t",
+		text := tr(ctx, "Please provide args <code> y/yes/true/on/n/no/false/off </code> to update the %s setting.\nUsage: <code>!%s args</code>\n\nCurrent setting: %t",
 			settingName, strings.ToLower(settingName), currentSetting)
 		_, err := msg.Reply(b, text, helpers.Shtml())
 		return err
@@ -50,12 +57,12 @@ func updateUserSettingHandler(b *gotgbot.Bot, ctx *ext.Context, settingName stri
 	// Update the setting based on the argument
 	if args[0] == "y" || args[0] == "yes" || args[0] == "true" || args[0] == "on" {
 		updateFunc(user.Id, true)
-		_, _ = msg.Reply(b, fmt.Sprintf("%s has been <b>enabled</b>.", settingName), helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "%s has been <b>enabled</b>.", settingName), helpers.Shtml())
 	} else if args[0] == "n" || args[0] == "no" || args[0] == "false" || args[0] == "off" {
 		updateFunc(user.Id, false)
-		_, _ = msg.Reply(b, fmt.Sprintf("%s has been <b>disabled</b>.", settingName), helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "%s has been <b>disabled</b>.", settingName), helpers.Shtml())
 	} else {
-		_, _ = msg.Reply(b, fmt.Sprintf("Invalid argument. Please provide <code> y/yes/true/on/n/no/false/off </code>  to update the %s setting.", settingName), helpers.Shtml())
+		_, _ = msg.Reply(b, tr(ctx, "Invalid argument. Please provide <code> y/yes/true/on/n/no/false/off </code>  to update the %s setting.", settingName), helpers.Shtml())
 	}
 
 	return nil
@@ -85,8 +92,354 @@ func updateCaptionAbove(b *gotgbot.Bot, ctx *ext.Context) error {
 	return updateUserSettingHandler(b, ctx, "CaptionAbove", db.UpdateCaptionAbove)
 }
 
+func updateNoTracking(b *gotgbot.Bot, ctx *ext.Context) error {
+	return updateUserSettingHandler(b, ctx, "NoTracking", db.UpdateNoTracking)
+}
+
 func resetSettings(b *gotgbot.Bot, ctx *ext.Context) error {
 	db.ResetUserSettings(ctx.EffectiveMessage.From.Id)
-	_, _ = ctx.EffectiveMessage.Reply(b, "All settings have been reset.", helpers.Shtml())
+	_, _ = ctx.EffectiveMessage.Reply(b, tr(ctx, "All settings have been reset."), helpers.Shtml())
 	return nil
 }
+
+// chatOverrideFields maps the setting names of /chatsettings to their fields in db.SettingOverrides
+var chatOverrideFields = []struct {
+	Name  string
+	Field string
+	Label string
+	Value func(db.SettingOverrides) *bool
+}{
+	{"silent", "nonotif", i18n.N("Silent"), func(o db.SettingOverrides) *bool { return o.NoNotif }},
+	{"protect", "protect", i18n.N("Protect content"), func(o db.SettingOverrides) *bool { return o.Protect }},
+	{"spoiler", "spoiler", i18n.N("Spoiler"), func(o db.SettingOverrides) *bool { return o.Spoiler }},
+	{"preview", "webpreview", i18n.N("No link preview"), func(o db.SettingOverrides) *bool { return o.WebPreview }},
+	{"captionabove", "captionabove", i18n.N("Caption above"), func(o db.SettingOverrides) *bool { return o.CaptionAbove }},
+	{"forward", "forwardtag", i18n.N("Forward tag"), func(o db.SettingOverrides) *bool { return o.ForwardTag }},
+}
+
+// formatOverrides lists the overrides of a chat next to the user's setting they replace
+func formatOverrides(lang string, overrides db.SettingOverrides, userSetting *db.UserSettings) string {
+	effective := overrides.Apply(userSetting)
+	values := []bool{effective.NoNotif, effective.Protect, effective.Spoiler, effective.WebPreview, effective.CaptionAbove, effective.ForwardTag}
+
+	var text strings.Builder
+	for i, field := range chatOverrideFields {
+		source := i18n.T(lang, "user setting")
+		if field.Value(overrides) != nil {
+			source = i18n.T(lang, "<b>chat override</b>")
+		}
+		text.WriteString(fmt.Sprintf("<code>%s</code>: %t (%s)\n", field.Name, values[i], source))
+	}
+	return text.String()
+}
+
+func chatSettings(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		var text strings.Builder
+		text.WriteString(tr(ctx, "Override your settings in one chat, for example to keep a channel always silent.\n"+
+			"Usage: <code>/chatsettings chat_id</code> to show the settings of a chat\n"+
+			"<code>/chatsettings chat_id setting on|off|default</code> to change one, <code>default</code> uses your setting again\n"+
+			"<code>/chatsettings chat_id reset</code> to remove all overrides of a chat\n\n"+
+			"Settings: <code>silent</code>, <code>protect</code>, <code>spoiler</code>, <code>preview</code>, <code>captionabove</code>, <code>forward</code>\n"))
+
+		settings, _ := db.ListChatSettings(msg.From.Id)
+		var chats []string
+		for _, setting := range settings {
+			if setting.Overrides != (db.SettingOverrides{}) {
+				chats = append(chats, fmt.Sprintf("<code>%d</code>", setting.ChatId))
+			}
+		}
+		if len(chats) > 0 {
+			text.WriteString(tr(ctx, "\n<b>Chats with overrides:</b> %s\n", strings.Join(chats, ", ")))
+		}
+
+		_, err := msg.Reply(b, text.String(), helpers.Shtml())
+		return err
+	}
+
+	chatId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || !helpers.Contains(db.Connection(msg.From.Id).ChatIds, chatId) {
+		_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is not one of your connected chats.", html.EscapeString(args[0])), helpers.Shtml())
+		return err
+	}
+
+	userSetting := db.GetUserSettings(msg.From.Id)
+	if len(args) == 1 {
+		text := tr(ctx, "<b>Settings of %d:</b>\n", chatId) + formatOverrides(i18n.Lang(msg.From), db.GetChatSettings(msg.From.Id, chatId).Overrides, userSetting)
+		_, err = msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	if args[1] == "reset" {
+		if err = db.SetChatOverride(msg.From.Id, chatId, "", nil); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error saving the chat settings."), helpers.Shtml())
+			return err
+		}
+		_, err = msg.Reply(b, tr(ctx, "All overrides of <code>%d</code> were removed, your settings apply again.", chatId), helpers.Shtml())
+		return err
+	}
+
+	if len(args) < 3 {
+		_, err = msg.Reply(b, tr(ctx, "Usage: <code>/chatsettings chat_id setting on|off|default</code>"), helpers.Shtml())
+		return err
+	}
+
+	field := ""
+	for _, f := range chatOverrideFields {
+		if f.Name == strings.ToLower(args[1]) {
+			field = f.Field
+		}
+	}
+	if field == "" {
+		_, err = msg.Reply(b, tr(ctx, "Unknown setting <code>%s</code>.", html.EscapeString(args[1])), helpers.Shtml())
+		return err
+	}
+
+	var value *bool
+	switch strings.ToLower(args[2]) {
+	case "y", "yes", "true", "on":
+		value = new(bool)
+		*value = true
+	case "n", "no", "false", "off":
+		value = new(bool)
+	case "default":
+	default:
+		_, err = msg.Reply(b, tr(ctx, "Please use <code>on</code>, <code>off</code> or <code>default</code>."), helpers.Shtml())
+		return err
+	}
+
+	if err = db.SetChatOverride(msg.From.Id, chatId, field, value); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the chat settings."), helpers.Shtml())
+		return err
+	}
+
+	text := tr(ctx, "<b>Settings of %d updated:</b>\n", chatId) + formatOverrides(i18n.Lang(msg.From), db.GetChatSettings(msg.From.Id, chatId).Overrides, userSetting)
+	_, err = msg.Reply(b, text, helpers.Shtml())
+	return err
+}
+
+// userSettingToggles are the settings shown on the /settings panel, Key is used in callback data
+var userSettingToggles = []struct {
+	Key   string
+	Label string
+	Get   func(*db.UserSettings) bool
+	Set   func(int64, bool)
+}{
+	{"silent", i18n.N("Silent"), func(s *db.UserSettings) bool { return s.NoNotif }, db.UpdateNoNotif},
+	{"protect", i18n.N("Protect content"), func(s *db.UserSettings) bool { return s.Protect }, db.UpdateProtect},
+	{"spoiler", i18n.N("Spoiler"), func(s *db.UserSettings) bool { return s.Spoiler }, db.UpdateSpoiler},
+	{"preview", i18n.N("No link preview"), func(s *db.UserSettings) bool { return s.WebPreview }, db.UpdateWebPreview},
+	{"captionabove", i18n.N("Caption above"), func(s *db.UserSettings) bool { return s.CaptionAbove }, db.UpdateCaptionAbove},
+	{"forward", i18n.N("Forward tag"), func(s *db.UserSettings) bool { return s.ForwardTag }, db.UpdateForwardTag},
+	{"notracking", i18n.N("No click tracking"), func(s *db.UserSettings) bool { return s.NoTracking }, db.UpdateNoTracking},
+}
+
+// panelTimezones are the timezones offered on the /settings panel, others can be set with /settings timezone
+var panelTimezones = []string{
+	"UTC", "Europe/London", "Europe/Berlin", "Europe/Moscow", "Asia/Dubai", "Asia/Kolkata", "Asia/Jakarta",
+	"Asia/Shanghai", "Asia/Tokyo", "Australia/Sydney", "America/Sao_Paulo", "America/New_York", "America/Chicago", "America/Los_Angeles",
+}
+
+func toggleLabel(on bool, label string) string {
+	if on {
+		return "✅ " + label
+	}
+	return "❌ " + label
+}
+
+// buttonRows lays out buttons two per row
+func buttonRows(buttons []gotgbot.InlineKeyboardButton) [][]gotgbot.InlineKeyboardButton {
+	var rows [][]gotgbot.InlineKeyboardButton
+	for i := 0; i < len(buttons); i += 2 {
+		rows = append(rows, buttons[i:min(i+2, len(buttons))])
+	}
+	return rows
+}
+
+// settingsMain returns the main page of the /settings panel
+func settingsMain(lang string, userId int64) (string, gotgbot.InlineKeyboardMarkup) {
+	settings := db.GetUserSettings(userId)
+	timezone := settings.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	text := i18n.T(lang, "<b>⚙️ Settings</b>\n\nTap a setting to turn it on or off, it applies to all your chats unless a chat overrides it.\n\n"+
+		"<b>Timezone:</b> <code>%s</code> (%s)", timezone, helpers.FormatTime(time.Now(), settings.Timezone))
+
+	var buttons []gotgbot.InlineKeyboardButton
+	for _, toggle := range userSettingToggles {
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: toggleLabel(toggle.Get(settings), i18n.T(lang, toggle.Label)), CallbackData: "settings.t." + toggle.Key})
+	}
+	rows := buttonRows(buttons)
+	rows = append(rows,
+		[]gotgbot.InlineKeyboardButton{{Text: i18n.T(lang, "📢 Chat overrides"), CallbackData: "settings.chats"}, {Text: i18n.T(lang, "🕒 Timezone"), CallbackData: "settings.tz"}},
+		[]gotgbot.InlineKeyboardButton{{Text: i18n.T(lang, "♻️ Reset"), CallbackData: "settings.reset"}, {Text: i18n.T(lang, "Close"), CallbackData: "settings.close"}},
+	)
+	return text, gotgbot.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// settingsChats returns the page listing the connected chats whose settings can be overridden
+func settingsChats(lang string, userId int64) (string, gotgbot.InlineKeyboardMarkup) {
+	var rows [][]gotgbot.InlineKeyboardButton
+	for _, chatId := range db.Connection(userId).ChatIds {
+		title := fmt.Sprint(chatId)
+		if getChat := onlyAdmins.GetChatCache(chatId); getChat.Cached {
+			title = getChat.ChatInfo.Title
+		}
+		if db.GetChatSettings(userId, chatId).Overrides != (db.SettingOverrides{}) {
+			title = "✏️ " + title
+		}
+		rows = append(rows, []gotgbot.InlineKeyboardButton{{Text: title, CallbackData: fmt.Sprintf("settings.chat.%d", chatId)}})
+	}
+	rows = append(rows, []gotgbot.InlineKeyboardButton{{Text: i18n.T(lang, "« Back"), CallbackData: "settings.main"}})
+
+	text := i18n.T(lang, "<b>📢 Chat overrides</b>\n\nChoose a chat to override your settings in it. Chats marked with ✏️ have overrides.")
+	if len(rows) == 1 {
+		text = i18n.T(lang, "<b>📢 Chat overrides</b>\n\nYou are not connected to any chats. Use <code>/add chat_id</code> to connect.")
+	}
+	return text, gotgbot.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// settingsChat returns the page with the overrides of one chat
+func settingsChat(lang string, userId, chatId int64) (string, gotgbot.InlineKeyboardMarkup) {
+	overrides := db.GetChatSettings(userId, chatId).Overrides
+	text := i18n.T(lang, "<b>📢 Settings of %d</b>\n\nTap a setting to switch between your setting, on and off.\n\n", chatId) +
+		formatOverrides(lang, overrides, db.GetUserSettings(userId))
+
+	var buttons []gotgbot.InlineKeyboardButton
+	for _, field := range chatOverrideFields {
+		label := "➖ " + i18n.T(lang, field.Label)
+		if value := field.Value(overrides); value != nil {
+			label = toggleLabel(*value, i18n.T(lang, field.Label))
+		}
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: label, CallbackData: fmt.Sprintf("settings.co.%d.%s", chatId, field.Name)})
+	}
+	rows := buttonRows(buttons)
+	rows = append(rows, []gotgbot.InlineKeyboardButton{
+		{Text: i18n.T(lang, "♻️ Use my settings"), CallbackData: fmt.Sprintf("settings.cr.%d", chatId)},
+		{Text: i18n.T(lang, "« Back"), CallbackData: "settings.chats"},
+	})
+	return text, gotgbot.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+// settingsTimezones returns the page to pick a timezone
+func settingsTimezones(lang string) (string, gotgbot.InlineKeyboardMarkup) {
+	var buttons []gotgbot.InlineKeyboardButton
+	for i, timezone := range panelTimezones {
+		buttons = append(buttons, gotgbot.InlineKeyboardButton{Text: timezone, CallbackData: fmt.Sprintf("settings.tz.%d", i)})
+	}
+	rows := append(buttonRows(buttons), []gotgbot.InlineKeyboardButton{{Text: i18n.T(lang, "« Back"), CallbackData: "settings.main"}})
+
+	text := i18n.T(lang, "<b>🕒 Timezone</b>\n\nTimes are shown in this timezone. For other timezones use <code>/settings timezone Area/City</code>.")
+	return text, gotgbot.InlineKeyboardMarkup{InlineKeyboard: rows}
+}
+
+func settingsPanel(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) > 0 && (args[0] == "timezone" || args[0] == "tz") {
+		if len(args) < 2 || !helpers.ValidTimezone(args[1]) {
+			_, err := msg.Reply(b, tr(ctx, "Please give a timezone like <code>Europe/Berlin</code> or <code>UTC</code>."), helpers.Shtml())
+			return err
+		}
+		db.UpdateTimezone(msg.From.Id, args[1])
+		_, err := msg.Reply(b, tr(ctx, "Timezone set to <code>%s</code>.", html.EscapeString(args[1])), helpers.Shtml())
+		return err
+	}
+
+	text, keyboard := settingsMain(i18n.Lang(msg.From), msg.From.Id)
+	opts := helpers.Shtml()
+	opts.ReplyMarkup = keyboard
+	_, err := msg.Reply(b, text, opts)
+	return err
+}
+
+func settingsCallback(b *gotgbot.Bot, ctx *ext.Context) error {
+	query := ctx.Update.CallbackQuery
+	userId := query.From.Id
+	parts := strings.Split(strings.TrimPrefix(query.Data, "settings."), ".")
+	lang := i18n.Lang(&query.From)
+
+	var text string
+	var keyboard gotgbot.InlineKeyboardMarkup
+	switch {
+	case parts[0] == "close":
+		_, _ = query.Answer(b, nil)
+		_, err := b.DeleteMessage(query.Message.GetChat().Id, query.Message.GetMessageId(), nil)
+		return err
+	case parts[0] == "t" && len(parts) == 2:
+		settings := db.GetUserSettings(userId)
+		for _, toggle := range userSettingToggles {
+			if toggle.Key == parts[1] {
+				toggle.Set(userId, !toggle.Get(settings))
+			}
+		}
+		text, keyboard = settingsMain(lang, userId)
+	case parts[0] == "reset":
+		db.ResetUserSettings(userId)
+		text, keyboard = settingsMain(lang, userId)
+	case parts[0] == "chats":
+		text, keyboard = settingsChats(lang, userId)
+	case (parts[0] == "chat" || parts[0] == "cr" || parts[0] == "co") && len(parts) >= 2:
+		chatId, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || !helpers.Contains(db.Connection(userId).ChatIds, chatId) {
+			_, _ = query.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: i18n.T(lang, "This chat is no longer connected."), ShowAlert: true})
+			return nil
+		}
+
+		if parts[0] == "cr" {
+			_ = db.SetChatOverride(userId, chatId, "", nil)
+		}
+		if parts[0] == "co" && len(parts) == 3 {
+			overrides := db.GetChatSettings(userId, chatId).Overrides
+			for _, field := range chatOverrideFields {
+				if field.Name != parts[2] {
+					continue
+				}
+				// Cycle through the user's setting, on and off
+				var value *bool
+				switch current := field.Value(overrides); {
+				case current == nil:
+					value = new(bool)
+					*value = true
+				case *current:
+					value = new(bool)
+				}
+				_ = db.SetChatOverride(userId, chatId, field.Field, value)
+			}
+		}
+		text, keyboard = settingsChat(lang, userId, chatId)
+	case parts[0] == "tz" && len(parts) == 2:
+		if i, err := strconv.Atoi(parts[1]); err == nil && i >= 0 && i < len(panelTimezones) {
+			db.UpdateTimezone(userId, panelTimezones[i])
+		}
+		text, keyboard = settingsMain(lang, userId)
+	case parts[0] == "tz":
+		text, keyboard = settingsTimezones(lang)
+	default:
+		text, keyboard = settingsMain(lang, userId)
+	}
+
+	_, _ = query.Answer(b, nil)
+	_, _, err := b.EditMessageText(text, &gotgbot.EditMessageTextOpts{
+		ChatId:      query.Message.GetChat().Id,
+		MessageId:   query.Message.GetMessageId(),
+		ParseMode:   gotgbot.ParseModeHTML,
+		ReplyMarkup: keyboard,
+	})
+	if err != nil && strings.Contains(err.Error(), "message is not modified") {
+		return nil
+	}
+	return err
+}
@@ -1,18 +1,19 @@
 package helpers
 
 import (
+	"AshokShau/channelManager/src/modules/utils/i18n"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 )
 
-func PostButton(postId string) gotgbot.InlineKeyboardMarkup {
+func PostButton(lang, postId string) gotgbot.InlineKeyboardMarkup {
 	return gotgbot.InlineKeyboardMarkup{
 		InlineKeyboard: [][]gotgbot.InlineKeyboardButton{
 			{
-				{Text: "Delete Post", CallbackData: fmt.Sprintf("delete.%s", postId)},
+				{Text: i18n.T(lang, "Delete Post"), CallbackData: fmt.Sprintf("delete.%s", postId)},
 			},
 			{
-				{Text: "Repost Post", CallbackData: fmt.Sprintf("repost.%s", postId)},
+				{Text: i18n.T(lang, "Repost Post"), CallbackData: fmt.Sprintf("repost.%s", postId)},
 			},
 		},
 	}
@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxFeedSize is the largest feed document that is read
+const maxFeedSize = 5 << 20
+
+var feedClient = &http.Client{Timeout: 30 * time.Second, Transport: PublicTransport()}
+
+// FetchFeed loads a feed document from an http(s) URL, or from a local file for file:// URLs
+func FetchFeed(feedUrl string) ([]byte, error) {
+	parsed, err := url.Parse(feedUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.Scheme == "file" {
+		file, err := os.Open(parsed.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(io.LimitReader(file, maxFeedSize))
+	}
+
+	if !IsWebURL(feedUrl) {
+		return nil, errors.New("feeds must be http(s) URLs")
+	}
+	return fetchURL(feedClient, feedUrl, maxFeedSize)
+}
+
+// IsWebURL reports whether s is an http(s) URL
+func IsWebURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://")
+}
+
+func fetchURL(client *http.Client, rawUrl string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "ChannelManagerBot/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error is shown to the user, keep the addresses the host resolved to out of it
+		if errors.Is(err, ErrPrivateAddress) {
+			return nil, ErrPrivateAddress
+		}
+		return nil, fmt.Errorf("%s could not be reached", req.URL.Host)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with status %s", req.URL.Host, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
@@ -2,18 +2,41 @@ package helpers
 
 import (
 	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/i18n"
 	"fmt"
 	tgmd2html "github.com/PaulSonOfLars/gotg_md2html"
 	"github.com/PaulSonOfLars/gotgbot/v2"
-	"regexp"
+	"html"
+	"strconv"
 	"strings"
 )
 
+// buttonPrefixes maps each db button type to its markdown prefix
+var buttonPrefixes = map[string]string{
+	db.ButtonUrl:   "buttonurl:",
+	db.ButtonReact: "buttonreact:",
+	db.ButtonAlert: "buttonalert:",
+	db.ButtonShare: "buttonshare:",
+	db.ButtonCopy:  "buttoncopy:",
+}
+
+// Telegram limits for the content of the extended button types
+const (
+	maxAlertLength = 200
+	maxCopyLength  = 256
+)
+
+var buttonConverter = tgmd2html.NewV2(buttonPrefixes)
+
 // RevertButtons converts []db.Button to a string
 func RevertButtons(buttons []db.Button) string {
 	var res string
 	for _, btn := range buttons {
-		format := "\n[%s](buttonurl://%s"
+		prefix, ok := buttonPrefixes[btn.Type]
+		if !ok {
+			prefix = buttonPrefixes[db.ButtonUrl]
+		}
+		format := "\n[%s](" + prefix + "//%s"
 		if btn.SameLine {
 			format += ":same"
 		}
@@ -23,27 +46,81 @@ func RevertButtons(buttons []db.Button) string {
 	return res
 }
 
+// ReactionKey returns the key under which votes for a reaction button are counted.
+func ReactionKey(btn db.Button) string {
+	if btn.Url != "" {
+		return btn.Url
+	}
+	return btn.Name
+}
+
 // BuildKeyboard builds a keyboard from a list of buttons.
 func BuildKeyboard(buttons []db.Button) [][]gotgbot.InlineKeyboardButton {
+	return BuildReactionKeyboard(buttons, nil)
+}
+
+// BuildReactionKeyboard builds a keyboard from a list of buttons, showing the vote counts next to reaction buttons.
+func BuildReactionKeyboard(buttons []db.Button, counts map[string]int) [][]gotgbot.InlineKeyboardButton {
 	keyB := make([][]gotgbot.InlineKeyboardButton, 0)
-	for _, btn := range buttons {
+	for i, btn := range buttons {
+		var button gotgbot.InlineKeyboardButton
+		switch btn.Type {
+		case db.ButtonReact:
+			button = gotgbot.InlineKeyboardButton{Text: reactionLabel(btn, counts), CallbackData: fmt.Sprintf("react.%d", i)}
+		case db.ButtonAlert:
+			button = gotgbot.InlineKeyboardButton{Text: btn.Name, CallbackData: fmt.Sprintf("alert.%d", i)}
+		case db.ButtonShare:
+			query := btn.Url
+			button = gotgbot.InlineKeyboardButton{Text: btn.Name, SwitchInlineQuery: &query}
+		case db.ButtonCopy:
+			button = gotgbot.InlineKeyboardButton{Text: btn.Name, CopyText: &gotgbot.CopyTextButton{Text: btn.Url}}
+		default:
+			button = gotgbot.InlineKeyboardButton{Text: btn.Name, Url: btn.Url}
+		}
+
 		if btn.SameLine && len(keyB) > 0 {
-			keyB[len(keyB)-1] = append(keyB[len(keyB)-1], gotgbot.InlineKeyboardButton{Text: btn.Name, Url: btn.Url})
+			keyB[len(keyB)-1] = append(keyB[len(keyB)-1], button)
 		} else {
-			k := make([]gotgbot.InlineKeyboardButton, 1)
-			k[0] = gotgbot.InlineKeyboardButton{Text: btn.Name, Url: btn.Url}
-			keyB = append(keyB, k)
+			keyB = append(keyB, []gotgbot.InlineKeyboardButton{button})
 		}
 	}
 	return keyB
 }
 
+// reactionLabel is the text of a reaction button, with its vote count if it has votes
+func reactionLabel(btn db.Button, counts map[string]int) string {
+	if count := counts[ReactionKey(btn)]; count > 0 {
+		return fmt.Sprintf("%s %d", btn.Name, count)
+	}
+	return btn.Name
+}
+
+// CountReactions returns a copy of the keyboard of a sent post with the vote counts on its reaction buttons.
+// The other buttons stay as they were sent, with the chat's default buttons and tracked links.
+func CountReactions(markup gotgbot.InlineKeyboardMarkup, buttons []db.Button, counts map[string]int) gotgbot.InlineKeyboardMarkup {
+	keyboard := make([][]gotgbot.InlineKeyboardButton, len(markup.InlineKeyboard))
+	for i, row := range markup.InlineKeyboard {
+		keyboard[i] = append([]gotgbot.InlineKeyboardButton{}, row...)
+		for j, button := range row {
+			index, ok := strings.CutPrefix(button.CallbackData, "react.")
+			if !ok {
+				continue
+			}
+			if n, err := strconv.Atoi(index); err == nil && n >= 0 && n < len(buttons) && buttons[n].Type == db.ButtonReact {
+				keyboard[i][j].Text = reactionLabel(buttons[n], counts)
+			}
+		}
+	}
+	return gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
 // ConvertButtonV2ToDbButton converts a list of ButtonV2 to a list of db.Button.
 func ConvertButtonV2ToDbButton(buttons []tgmd2html.ButtonV2) (btnS []db.Button) {
 	btnS = make([]db.Button, len(buttons))
 	for i, btn := range buttons {
 		btnS[i] = db.Button{
 			Name:     btn.Name,
+			Type:     btn.Type,
 			Url:      btn.Content,
 			SameLine: btn.SameLine,
 		}
@@ -51,17 +128,97 @@ func ConvertButtonV2ToDbButton(buttons []tgmd2html.ButtonV2) (btnS []db.Button)
 	return
 }
 
-// InlineKeyboardMarkupToTgmd2htmlButtonV2 converts gotgbot.InlineKeyboardMarkup to []tgmd2html.ButtonV2.
-func InlineKeyboardMarkupToTgmd2htmlButtonV2(replyMarkup *gotgbot.InlineKeyboardMarkup) (btns []tgmd2html.ButtonV2) {
-	for _, inlineKeyboard := range replyMarkup.InlineKeyboard {
+// storedButtons returns the buttons of the post a message was delivered as, nil if it is not a post.
+// Messages forwarded from a channel are looked up by their origin.
+func storedButtons(m *gotgbot.Message) []db.Button {
+	chatId, msgId := m.Chat.Id, m.MessageId
+	if m.ForwardOrigin != nil {
+		if origin := m.ForwardOrigin.MergeMessageOrigin(); origin.Type == "channel" && origin.Chat != nil {
+			chatId, msgId = origin.Chat.Id, origin.MessageId
+		}
+	}
+
+	post, err := db.GetPostByMessage(chatId, msgId)
+	if err == nil && post == nil && m.From != nil && m.From.IsBot && m.Chat.Type == "private" {
+		// Post previews from !create are stored under the bot's id
+		post, err = db.GetPostByMessage(m.From.Id, msgId)
+	}
+	if err != nil || post == nil {
+		return nil
+	}
+	return post.Buttons
+}
+
+// InlineKeyboardMarkupToTgmd2htmlButtonV2 converts the keyboard of a message to []tgmd2html.ButtonV2.
+// The control rows of post previews, from the send button on, are left out. Alert buttons only carry their
+// index, their text is taken from the stored post and they are dropped if it is not found.
+func InlineKeyboardMarkupToTgmd2htmlButtonV2(m *gotgbot.Message) (btns []tgmd2html.ButtonV2) {
+	var stored []db.Button
+	storedLoaded := false
+
+	for _, inlineKeyboard := range m.ReplyMarkup.InlineKeyboard {
 		for i, button := range inlineKeyboard {
+			sameline := i != 0
+			if strings.HasPrefix(button.CallbackData, "send.") {
+				// The preview controls of !create and !get follow the post's own buttons
+				return
+			}
+			if strings.HasPrefix(button.CallbackData, "react.") {
+				// Strip the vote counter from the button text
+				name := button.Text
+				if idx := strings.LastIndex(name, " "); idx > 0 {
+					if _, err := strconv.Atoi(name[idx+1:]); err == nil {
+						name = name[:idx]
+					}
+				}
+				btns = append(btns, tgmd2html.ButtonV2{
+					Name:     name,
+					Type:     db.ButtonReact,
+					SameLine: sameline,
+				})
+				continue
+			}
+			if index, ok := strings.CutPrefix(button.CallbackData, "alert."); ok {
+				if !storedLoaded {
+					stored, storedLoaded = storedButtons(m), true
+				}
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 || n >= len(stored) || stored[n].Type != db.ButtonAlert {
+					continue
+				}
+				btns = append(btns, tgmd2html.ButtonV2{
+					Name:     button.Text,
+					Type:     db.ButtonAlert,
+					Content:  stored[n].Url,
+					SameLine: sameline,
+				})
+				continue
+			}
+			if button.CopyText != nil {
+				btns = append(btns, tgmd2html.ButtonV2{
+					Name:     button.Text,
+					Type:     db.ButtonCopy,
+					Content:  button.CopyText.Text,
+					SameLine: sameline,
+				})
+				continue
+			}
+			if button.SwitchInlineQuery != nil {
+				btns = append(btns, tgmd2html.ButtonV2{
+					Name:     button.Text,
+					Type:     db.ButtonShare,
+					Content:  *button.SwitchInlineQuery,
+					SameLine: sameline,
+				})
+				continue
+			}
 			if button.Url == "" {
 				continue
 			}
-			sameline := i != 0
 			btns = append(btns, tgmd2html.ButtonV2{
 				Name:     button.Text,
-				Content:  button.Url,
+				Type:     db.ButtonUrl,
+				Content:  UntrackUrl(button.Url),
 				SameLine: sameline,
 			})
 		}
@@ -69,33 +226,97 @@ func InlineKeyboardMarkupToTgmd2htmlButtonV2(replyMarkup *gotgbot.InlineKeyboard
 	return
 }
 
+// checkButtons names unnamed buttons, validates the content of every button and normalizes url button links.
+// It returns an error message describing the invalid buttons, or an empty string if all are valid.
+func checkButtons(lang string, buttons []tgmd2html.ButtonV2, defaultNameButton string) string {
+	for i, button := range buttons {
+		if button.Name == "" {
+			buttons[i].Name = defaultNameButton
+		}
+
+		length := TextLength(button.Content)
+		if button.Type == db.ButtonAlert && length > maxAlertLength {
+			return i18n.T(lang, "The alert of button \"%s\" is %d characters long. The maximum alert length is %d.", buttons[i].Name, length, maxAlertLength)
+		}
+		if button.Type == db.ButtonCopy && length > maxCopyLength {
+			return i18n.T(lang, "The copy text of button \"%s\" is %d characters long. The maximum copy text length is %d.", buttons[i].Name, length, maxCopyLength)
+		}
+	}
+
+	// Validate and normalize the links of url buttons, reporting every bad one
+	var invalidButtons []string
+	for i, btn := range buttons {
+		if btn.Type != db.ButtonUrl {
+			continue
+		}
+
+		link, err := NormalizeButtonUrl(btn.Content)
+		if err != nil {
+			invalidButtons = append(invalidButtons, fmt.Sprintf("- <b>%s</b>: <code>%s</code> (%s)",
+				html.EscapeString(buttons[i].Name), html.EscapeString(btn.Content), html.EscapeString(err.Error())))
+			continue
+		}
+		buttons[i].Content = link
+	}
+
+	if len(invalidButtons) > 0 {
+		return i18n.T(lang, "Some buttons have invalid links, please fix them and try again:\n%s", strings.Join(invalidButtons, "\n"))
+	}
+	return ""
+}
+
+// ParseFooter converts a markdown footer with buttons into HTML and db buttons.
+// Only buttons that need no post lookup (url, share and copy) are allowed, as footer buttons are not stored on posts.
+func ParseFooter(lang, raw string) (text string, buttons []db.Button, errorMsg string) {
+	text, _buttons := buttonConverter.MD2HTMLButtons(raw)
+	for _, btn := range _buttons {
+		if btn.Type == db.ButtonReact || btn.Type == db.ButtonAlert {
+			return "", nil, i18n.T(lang, "The button \"%s\" can't be used in a footer, only url, share and copy buttons are supported.", html.EscapeString(btn.Name))
+		}
+	}
+
+	if errorMsg = checkButtons(lang, _buttons, "Button"); errorMsg != "" {
+		return "", nil, errorMsg
+	}
+
+	return strings.Trim(text, "\n\t\r "), ConvertButtonV2ToDbButton(_buttons), ""
+}
+
+// CheckPostButtons validates buttons that were not written in markdown, such as the buttons of API requests,
+// the same way the buttons of a message are. It returns the normalized buttons or an error message.
+func CheckPostButtons(buttons []db.Button) ([]db.Button, string) {
+	_buttons := make([]tgmd2html.ButtonV2, len(buttons))
+	for i, btn := range buttons {
+		if btn.Type == "" {
+			btn.Type = db.ButtonUrl
+		}
+		if _, ok := buttonPrefixes[btn.Type]; !ok {
+			return nil, i18n.T(i18n.Default, "The button \"%s\" has the unknown type \"%s\".", html.EscapeString(btn.Name), html.EscapeString(btn.Type))
+		}
+		_buttons[i] = tgmd2html.ButtonV2{Name: btn.Name, Type: btn.Type, Content: btn.Url, SameLine: btn.SameLine}
+	}
+
+	if errorMsg := checkButtons(i18n.Default, _buttons, "Button"); errorMsg != "" {
+		return nil, errorMsg
+	}
+	return ConvertButtonV2ToDbButton(_buttons), ""
+}
+
 // preFixes checks the message before saving it to a database.
-func preFixes(buttons []tgmd2html.ButtonV2, defaultNameButton string, text *string, dataType *int, fileid string, dbButtons *[]db.Button, errorMsg *string) {
+func preFixes(lang string, buttons []tgmd2html.ButtonV2, defaultNameButton string, text *string, dataType *int, fileid string, dbButtons *[]db.Button, errorMsg *string) {
 	if *dataType == db.TEXT && len(*text) > 4096 {
 		*dataType = -1
-		*errorMsg = fmt.Sprintf("Your message text is %d characters long. The maximum length for text is 4096; please trim it to a smaller size. Note that markdown characters may take more space than expected.", len(*text))
+		*errorMsg = i18n.T(lang, "Your message text is %d characters long. The maximum length for text is 4096; please trim it to a smaller size. Note that markdown characters may take more space than expected.", len(*text))
 	} else if *dataType != db.TEXT && len(*text) > 1024 {
 		*dataType = -1
-		*errorMsg = fmt.Sprintf("Your message caption is %d characters long. The maximum caption length is 1024; please trim it to a smaller size. Note that markdown characters may take more space than expected.", len(*text))
+		*errorMsg = i18n.T(lang, "Your message caption is %d characters long. The maximum caption length is 1024; please trim it to a smaller size. Note that markdown characters may take more space than expected.", len(*text))
 	} else {
-		for i, button := range buttons {
-			if button.Name == "" {
-				buttons[i].Name = defaultNameButton
-			}
-		}
-
-		buttonUrlFixer := func(_buttons *[]tgmd2html.ButtonV2) {
-			buttonUrlPattern, _ := regexp.Compile(`[(htps)?:/w.a-zA-Z\d@%_+~#=]{2,256}\.[a-z]{2,6}\b([-a-zA-Z\d@:%_+.~#?&/=]*)`)
-			buttons = *_buttons
-			for i, btn := range *_buttons {
-				if !buttonUrlPattern.MatchString(btn.Content) {
-					buttons = append(buttons[:i], buttons[i+1:]...)
-				}
-			}
-			*_buttons = buttons
+		if msg := checkButtons(lang, buttons, defaultNameButton); msg != "" {
+			*dataType = -1
+			*errorMsg = msg
+			return
 		}
 
-		buttonUrlFixer(&buttons)
 		*dbButtons = ConvertButtonV2ToDbButton(buttons)
 
 		*text = strings.Trim(*text, "\n\t\r ")
@@ -124,9 +345,35 @@ func setRawText(msg *gotgbot.Message, args []string, rawText *string) {
 		}
 	}
 }
+
+// messageMedia returns the file id and post type of the media in a message, or -1 if it has none
+func messageMedia(m *gotgbot.Message) (string, int) {
+	switch {
+	case m.Sticker != nil:
+		return m.Sticker.FileId, db.STICKER
+	case m.Document != nil:
+		return m.Document.FileId, db.DOCUMENT
+	case len(m.Photo) > 0:
+		return m.Photo[len(m.Photo)-1].FileId, db.PHOTO
+	case m.Audio != nil:
+		return m.Audio.FileId, db.AUDIO
+	case m.Voice != nil:
+		return m.Voice.FileId, db.VOICE
+	case m.Video != nil:
+		return m.Video.FileId, db.VIDEO
+	case m.VideoNote != nil:
+		return m.VideoNote.FileId, db.VideoNote
+	case m.Animation != nil:
+		return m.Animation.FileId, db.GIF
+	default:
+		return "", -1
+	}
+}
+
 func GetMsgType(msg *gotgbot.Message) (text string, dataType int, fileId string, buttons []db.Button, errorMsg string) {
+	lang := i18n.Lang(msg.From)
 	dataType = -1
-	errorMsg = fmt.Sprintf("You need to give me some content to post!")
+	errorMsg = i18n.T(lang, "You need to give me some content to post!")
 	var (
 		rawText string
 		args    = strings.Fields(msg.Text)[1:]
@@ -140,49 +387,53 @@ func GetMsgType(msg *gotgbot.Message) (text string, dataType int, fileId string,
 
 	if len(args) >= 1 && msg.ReplyToMessage == nil {
 		fileId = ""
-		text, _buttons = tgmd2html.MD2HTMLButtonsV2(rawText)
+		text, _buttons = buttonConverter.MD2HTMLButtons(rawText)
 		dataType = db.TEXT
 	} else if msg.ReplyToMessage != nil {
 		if replyMsg.ReplyMarkup == nil {
-			text, _buttons = tgmd2html.MD2HTMLButtonsV2(rawText)
+			text, _buttons = buttonConverter.MD2HTMLButtons(rawText)
 		} else {
-			text, _ = tgmd2html.MD2HTMLButtonsV2(rawText)
-			_buttons = InlineKeyboardMarkupToTgmd2htmlButtonV2(replyMsg.ReplyMarkup)
+			text, _ = buttonConverter.MD2HTMLButtons(rawText)
+			_buttons = InlineKeyboardMarkupToTgmd2htmlButtonV2(replyMsg)
 		}
 		if len(args) == 0 && replyMsg.Text != "" {
 			dataType = db.TEXT
-		} else if replyMsg.Sticker != nil {
-			fileId = replyMsg.Sticker.FileId
-			dataType = db.STICKER
+		} else {
+			fileId, dataType = messageMedia(replyMsg)
 			// Extract buttons from args when the message is a sticker
-			if len(args) > 0 {
-				_, _buttons = tgmd2html.MD2HTMLButtonsV2(strings.Join(args, " "))
+			if dataType == db.STICKER && len(args) > 0 {
+				_, _buttons = buttonConverter.MD2HTMLButtons(strings.Join(args, " "))
 			}
-		} else if replyMsg.Document != nil {
-			fileId = replyMsg.Document.FileId
-			dataType = db.DOCUMENT
-		} else if len(replyMsg.Photo) > 0 {
-			fileId = replyMsg.Photo[len(replyMsg.Photo)-1].FileId
-			dataType = db.PHOTO
-		} else if replyMsg.Audio != nil {
-			fileId = replyMsg.Audio.FileId
-			dataType = db.AUDIO
-		} else if replyMsg.Voice != nil {
-			fileId = replyMsg.Voice.FileId
-			dataType = db.VOICE
-		} else if replyMsg.Video != nil {
-			fileId = replyMsg.Video.FileId
-			dataType = db.VIDEO
-		} else if replyMsg.VideoNote != nil {
-			fileId = replyMsg.VideoNote.FileId
-			dataType = db.VideoNote
-		} else if replyMsg.Animation != nil {
-			fileId = replyMsg.Animation.FileId
-			dataType = db.GIF
 		}
 	}
 
 	// pre-fix the data before sending it back
-	preFixes(_buttons, "Button", &text, &dataType, fileId, &buttons, &errorMsg)
+	preFixes(lang, _buttons, "Button", &text, &dataType, fileId, &buttons, &errorMsg)
+	return
+}
+
+// GetMessageContent extracts the post content of a message itself, like a channel post, the way GetMsgType does for
+// the message a command replies to.
+func GetMessageContent(m *gotgbot.Message) (text string, dataType int, fileId string, buttons []db.Button, errorMsg string) {
+	// Channel posts have no user to reply to, the error is only logged
+	lang := i18n.Default
+	errorMsg = i18n.T(lang, "You need to give me some content to post!")
+
+	rawText := m.OriginalMDV2()
+	if m.Text == "" {
+		rawText = m.OriginalCaptionMDV2()
+	}
+
+	text, _buttons := buttonConverter.MD2HTMLButtons(rawText)
+	if m.ReplyMarkup != nil {
+		_buttons = InlineKeyboardMarkupToTgmd2htmlButtonV2(m)
+	}
+
+	fileId, dataType = messageMedia(m)
+	if dataType == -1 && m.Text != "" {
+		dataType = db.TEXT
+	}
+
+	preFixes(lang, _buttons, "Button", &text, &dataType, fileId, &buttons, &errorMsg)
 	return
 }
@@ -8,6 +8,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	_ "time/tzdata" // timezones of users must load on hosts without zoneinfo
+	"unicode/utf16"
 )
 
 func ToInt64(s string) int64 {
@@ -20,6 +22,11 @@ func ToInt(s string) int {
 	return i
 }
 
+// TextLength returns the length of a text the way Telegram counts it against its limits, in UTF-16 code units
+func TextLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
 func Contains(slice []int64, value int64) bool {
 	for _, item := range slice {
 		if item == value {
@@ -63,3 +70,38 @@ func GetMessageLink(chatId, messageId int64) string {
 
 	return fmt.Sprintf("https://t.me/c/%s/%d", chatIdStr, messageId)
 }
+
+// ParseDuration parses a duration like "90m", "12h" or "3d"
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// ValidTimezone reports whether a timezone is an IANA name like "Europe/Berlin", empty means UTC
+func ValidTimezone(timezone string) bool {
+	if timezone == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(timezone)
+	return err == nil
+}
+
+// FormatTime formats a time in a user's timezone, UTC if the timezone is empty or unknown
+func FormatTime(t time.Time, timezone string) string {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return t.In(location).Format("2006-01-02 15:04 MST")
+}
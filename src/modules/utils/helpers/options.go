@@ -0,0 +1,140 @@
+package helpers
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"html"
+	"strings"
+)
+
+// sendOptionFlags are the flags post commands take to override the user's settings for one post
+var sendOptionFlags = map[string]func(o *db.SettingOverrides, on *bool, off *bool){
+	"--silent":        func(o *db.SettingOverrides, on, _ *bool) { o.NoNotif = on },
+	"--notify":        func(o *db.SettingOverrides, _, off *bool) { o.NoNotif = off },
+	"--protect":       func(o *db.SettingOverrides, on, _ *bool) { o.Protect = on },
+	"--no-protect":    func(o *db.SettingOverrides, _, off *bool) { o.Protect = off },
+	"--spoiler":       func(o *db.SettingOverrides, on, _ *bool) { o.Spoiler = on },
+	"--no-spoiler":    func(o *db.SettingOverrides, _, off *bool) { o.Spoiler = off },
+	"--no-preview":    func(o *db.SettingOverrides, on, _ *bool) { o.WebPreview = on },
+	"--preview":       func(o *db.SettingOverrides, _, off *bool) { o.WebPreview = off },
+	"--caption-above": func(o *db.SettingOverrides, on, _ *bool) { o.CaptionAbove = on },
+	"--caption-below": func(o *db.SettingOverrides, _, off *bool) { o.CaptionAbove = off },
+	"--forward":       func(o *db.SettingOverrides, on, _ *bool) { o.ForwardTag = on },
+	"--no-forward":    func(o *db.SettingOverrides, _, off *bool) { o.ForwardTag = off },
+}
+
+// SendOptionsHelp lists the flags of post commands
+const SendOptionsHelp = "<code>--silent</code>, <code>--protect</code>, <code>--spoiler</code>, <code>--no-preview</code>, " +
+	"<code>--caption-above</code>, <code>--forward</code> and their opposites <code>--notify</code>, <code>--no-protect</code>, " +
+	"<code>--no-spoiler</code>, <code>--preview</code>, <code>--caption-below</code>, <code>--no-forward</code>, " +
+	"and <code>--pin</code>, <code>--pin-silent</code>, <code>--unpin-after 12h</code>, <code>--comment text</code>"
+
+// maxCommentLength is the longest comment a post can have, the text limit of Telegram
+const maxCommentLength = 4096
+
+// SendOptions are the flags given to a post command
+type SendOptions struct {
+	Overrides *db.SettingOverrides // nil if no setting was overridden
+	Pin       *db.PinOptions       // nil if the post is not pinned
+	Comment   string               // HTML, the rest of the line after --comment
+}
+
+// ParseSendOptions reads the option flags that follow the command and its first skip arguments, like
+// "!send --silent --pin" or "!edit PostId --spoiler". It returns a copy of the message with the flags
+// removed, so the rest of the command parses as before, and the options.
+func ParseSendOptions(msg *gotgbot.Message, skip int) (*gotgbot.Message, SendOptions, string) {
+	text := msg.Text
+	line := text
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		line = text[:i]
+	}
+
+	// Find the start of each word of the first line
+	var starts []int
+	for i := 0; i < len(line); i++ {
+		if line[i] != ' ' && (i == 0 || line[i-1] == ' ') {
+			starts = append(starts, i)
+		}
+	}
+
+	var options SendOptions
+	first := 1 + skip
+	if len(starts) <= first || !strings.HasPrefix(line[starts[first]:], "--") {
+		return msg, options, ""
+	}
+
+	on, off := true, false
+	overrides := &db.SettingOverrides{}
+	pin := func() *db.PinOptions {
+		if options.Pin == nil {
+			options.Pin = &db.PinOptions{}
+		}
+		return options.Pin
+	}
+
+	lang := i18n.Lang(msg.From)
+	start, end := starts[first], len(line)
+flags:
+	for i := first; i < len(starts); i++ {
+		word, _, _ := strings.Cut(line[starts[i]:], " ")
+		if !strings.HasPrefix(word, "--") {
+			end = starts[i]
+			break
+		}
+
+		switch flag := strings.ToLower(word); flag {
+		case "--pin":
+			pin()
+		case "--pin-silent":
+			pin().Silent = true
+		case "--unpin-after":
+			value := ""
+			if i+1 < len(starts) {
+				i++
+				value, _, _ = strings.Cut(line[starts[i]:], " ")
+			}
+			duration, err := ParseDuration(value)
+			if err != nil {
+				return msg, SendOptions{}, i18n.T(lang, "<code>--unpin-after</code> needs a duration like <code>12h</code> or <code>3d</code>.")
+			}
+			pin().UnpinAfter = int64(duration.Seconds())
+		case "--comment":
+			// The comment is the last flag, it takes the rest of the line
+			comment := ""
+			if i+1 < len(starts) {
+				comment = strings.TrimSpace(line[starts[i+1]:])
+			}
+			if comment == "" {
+				return msg, SendOptions{}, i18n.T(lang, "<code>--comment</code> needs the text of the comment after it.")
+			}
+			if length := TextLength(comment); length > maxCommentLength {
+				return msg, SendOptions{}, i18n.T(lang, "Your comment is %d characters long. The maximum length for text is 4096.", length)
+			}
+			options.Comment = html.EscapeString(comment)
+			break flags
+		default:
+			set, ok := sendOptionFlags[flag]
+			if !ok {
+				return msg, SendOptions{}, i18n.T(lang, "Unknown option <code>%s</code>. Options are %s.", html.EscapeString(word), i18n.T(lang, SendOptionsHelp))
+			}
+			set(overrides, &on, &off)
+			options.Overrides = overrides
+		}
+	}
+
+	// Entities count UTF-16 code units, the comment may not be ASCII
+	start16, end16 := int64(TextLength(text[:start])), int64(TextLength(text[:end]))
+	stripped := *msg
+	stripped.Text = text[:start] + text[end:]
+	stripped.Entities = make([]gotgbot.MessageEntity, 0, len(msg.Entities))
+	for _, entity := range msg.Entities {
+		if entity.Offset >= end16 {
+			entity.Offset -= end16 - start16
+		} else if entity.Offset >= start16 {
+			continue
+		}
+		stripped.Entities = append(stripped.Entities, entity)
+	}
+	return &stripped, options, ""
+}
@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/i18n"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+	linkRegex    = regexp.MustCompile(`(?i)\b(?:https?://|t\.me/|www\.)[^\s"'<>]+`)
+	hashtagRegex = regexp.MustCompile(`#[\p{L}\p{N}_]+`)
+)
+
+// PlainText strips the HTML formatting of a post text
+func PlainText(text string) string {
+	return html.UnescapeString(htmlTagRegex.ReplaceAllString(text, ""))
+}
+
+// PostLinks returns the distinct links of a post, from its text, its formatting and its url buttons
+func PostLinks(text string, buttons []db.Button) []string {
+	var links []string
+	seen := make(map[string]bool)
+	add := func(link string) {
+		link = strings.TrimRight(link, ".,;:!?)")
+		if link != "" && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	for _, link := range linkRegex.FindAllString(html.UnescapeString(text), -1) {
+		add(link)
+	}
+	for _, button := range buttons {
+		if (button.Type == "" || button.Type == db.ButtonUrl) && !strings.HasPrefix(button.Url, "tg://") {
+			add(button.Url)
+		}
+	}
+	return links
+}
+
+// linkDomain returns the lowercase host of a link without "www."
+func linkDomain(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "https://" + link
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// matchDomain reports whether the domain is one of the given domains or a subdomain of one
+func matchDomain(domain string, domains []string) bool {
+	for _, d := range domains {
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether the text contains the word or phrase, ignoring case and parts of other words
+func containsWord(text, word string) bool {
+	pattern := `(?i)(^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(word) + `($|[^\p{L}\p{N}_])`
+	matched, _ := regexp.MatchString(pattern, text)
+	return matched
+}
+
+// CheckPolicy runs the checks of a policy on a post and returns a description of every violation in a language
+func CheckPolicy(lang string, policy *db.Policy, text string, buttons []db.Button) []string {
+	if policy == nil {
+		return nil
+	}
+
+	var violations []string
+	plain := PlainText(text)
+	for _, button := range buttons {
+		plain += "\n" + button.Name
+	}
+
+	for _, word := range policy.BannedWords {
+		if containsWord(plain, word) {
+			violations = append(violations, i18n.T(lang, "Contains the banned word <code>%s</code>", html.EscapeString(word)))
+		}
+	}
+
+	links := PostLinks(text, buttons)
+	if policy.MaxLinks != nil && len(links) > *policy.MaxLinks {
+		violations = append(violations, i18n.T(lang, "Has %d links, at most %d are allowed", len(links), *policy.MaxLinks))
+	}
+
+	for _, link := range links {
+		domain := linkDomain(link)
+		if matchDomain(domain, policy.DenyDomains) {
+			violations = append(violations, i18n.T(lang, "Links to the denied domain <code>%s</code>", html.EscapeString(domain)))
+		} else if len(policy.AllowDomains) > 0 && !matchDomain(domain, policy.AllowDomains) {
+			violations = append(violations, i18n.T(lang, "Links to <code>%s</code>, which is not an allowed domain", html.EscapeString(domain)))
+		}
+	}
+
+	tags := make(map[string]bool)
+	for _, tag := range hashtagRegex.FindAllString(plain, -1) {
+		tags[strings.ToLower(tag)] = true
+	}
+	for _, tag := range policy.RequiredTags {
+		if !tags[strings.ToLower(tag)] {
+			violations = append(violations, i18n.T(lang, "Missing the required hashtag <code>%s</code>", html.EscapeString(tag)))
+		}
+	}
+
+	return violations
+}
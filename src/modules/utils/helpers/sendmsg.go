@@ -31,7 +31,38 @@ func sendMedia[T any](b *gotgbot.Bot, chatID int64, fileID string, opts *T) (*go
 	}
 }
 
-var PostEnumFuncMap = map[int]func(b *gotgbot.Bot, ctx *ext.Context, chatId int64, msg, fileID string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) (*gotgbot.Message, error){
+// PhotoOpts returns the options photo posts are sent with
+func PhotoOpts(caption string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) *gotgbot.SendPhotoOpts {
+	return &gotgbot.SendPhotoOpts{
+		ParseMode:             gotgbot.ParseModeHTML,
+		ReplyMarkup:           keyB,
+		Caption:               caption,
+		DisableNotification:   userSetting.NoNotif,
+		ProtectContent:        userSetting.Protect,
+		ShowCaptionAboveMedia: userSetting.CaptionAbove,
+		HasSpoiler:            userSetting.Spoiler,
+	}
+}
+
+// PostSender sends a post of one type to a chat
+type PostSender func(b *gotgbot.Bot, ctx *ext.Context, chatId int64, msg, fileID string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) (*gotgbot.Message, error)
+
+// PostEnumFuncMap sends posts by type. The settings of the user are resolved for the destination chat,
+// so overrides set with /chatsettings apply.
+var PostEnumFuncMap = withChatOverrides(postSenders)
+
+// withChatOverrides wraps senders to apply the user's setting overrides of the chat they send to
+func withChatOverrides(senders map[int]PostSender) map[int]PostSender {
+	wrapped := make(map[int]PostSender, len(senders))
+	for msgType, send := range senders {
+		wrapped[msgType] = func(b *gotgbot.Bot, ctx *ext.Context, chatId int64, msg, fileID string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) (*gotgbot.Message, error) {
+			return send(b, ctx, chatId, msg, fileID, keyB, db.EffectiveSettings(userSetting, chatId))
+		}
+	}
+	return wrapped
+}
+
+var postSenders = map[int]PostSender{
 	db.TEXT: func(b *gotgbot.Bot, ctx *ext.Context, chatId int64, msg, _ string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) (*gotgbot.Message, error) {
 		opts := &gotgbot.SendMessageOpts{
 			ParseMode:           gotgbot.ParseModeHTML,
@@ -62,16 +93,7 @@ var PostEnumFuncMap = map[int]func(b *gotgbot.Bot, ctx *ext.Context, chatId int6
 		return sendMedia(b, chatId, fileID, opts)
 	},
 	db.PHOTO: func(b *gotgbot.Bot, ctx *ext.Context, chatId int64, msg, fileID string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) (*gotgbot.Message, error) {
-		opts := &gotgbot.SendPhotoOpts{
-			ParseMode:             gotgbot.ParseModeHTML,
-			ReplyMarkup:           keyB,
-			Caption:               msg,
-			DisableNotification:   userSetting.NoNotif,
-			ProtectContent:        userSetting.Protect,
-			ShowCaptionAboveMedia: userSetting.CaptionAbove,
-			HasSpoiler:            userSetting.Spoiler,
-		}
-		return sendMedia(b, chatId, fileID, opts)
+		return sendMedia(b, chatId, fileID, PhotoOpts(msg, keyB, userSetting))
 	},
 	db.AUDIO: func(b *gotgbot.Bot, ctx *ext.Context, chatId int64, msg, fileID string, keyB *gotgbot.InlineKeyboardMarkup, userSetting *db.UserSettings) (*gotgbot.Message, error) {
 		opts := &gotgbot.SendAudioOpts{
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"AshokShau/channelManager/src/config"
+	"AshokShau/channelManager/src/db"
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// clickTracking is set once the redirect endpoint is served by the HTTP listener
+var clickTracking atomic.Bool
+
+// EnableClickTracking turns on tracked links for url buttons of new posts.
+func EnableClickTracking() {
+	clickTracking.Store(true)
+}
+
+// trackingPrefix returns the prefix of all tracked links
+func trackingPrefix() string {
+	return strings.TrimSuffix(config.ClickTrackingUrl, "/") + "/c/"
+}
+
+// TrackButtons returns a copy of buttons in which url buttons point to tracked links for a post in chatId.
+// Buttons are returned unchanged if tracking is disabled or the user opted out.
+func TrackButtons(buttons []db.Button, postId string, chatId int64, userSetting *db.UserSettings) []db.Button {
+	if !clickTracking.Load() || userSetting.NoTracking {
+		return buttons
+	}
+
+	tracked := make([]db.Button, len(buttons))
+	copy(tracked, buttons)
+	for i, btn := range tracked {
+		if btn.Type != "" && btn.Type != db.ButtonUrl {
+			continue
+		}
+
+		token := newLinkToken()
+		if token == "" {
+			continue
+		}
+
+		err := db.AddLink(db.Link{Token: token, PostId: postId, ChatId: chatId, Button: i, Name: btn.Name, Url: btn.Url})
+		if err != nil {
+			continue
+		}
+		tracked[i].Url = trackingPrefix() + token
+	}
+	return tracked
+}
+
+// UntrackUrl returns the original url of a tracked link, other urls are returned as is.
+func UntrackUrl(url string) string {
+	if config.ClickTrackingUrl == "" || !strings.HasPrefix(url, trackingPrefix()) {
+		return url
+	}
+
+	link, err := db.GetLink(strings.TrimPrefix(url, trackingPrefix()))
+	if err != nil || link == nil {
+		return url
+	}
+	return link.Url
+}
+
+// newLinkToken generates a short random token for a tracked link
+func newLinkToken() string {
+	randomBytes := make([]byte, 6)
+	if _, err := rand.Read(randomBytes); err != nil {
+		log.Printf("Failed to generate link token: %v", err)
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{3,31}$`)
+	schemePattern   = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*):`)
+	hostLabel       = regexp.MustCompile(`^[\p{L}\d]([\p{L}\d-]*[\p{L}\d])?$`)
+	tldPattern      = regexp.MustCompile(`^(\p{L}{2,63}|xn--[a-zA-Z\d-]+)$`)
+)
+
+// NormalizeButtonUrl validates a button link and returns it in the form Telegram expects.
+// It accepts http(s) and tg:// links, t.me links and @username shorthand, and adds https:// when the scheme is missing.
+func NormalizeButtonUrl(raw string) (string, error) {
+	link := strings.TrimSpace(raw)
+	if link == "" {
+		return "", errors.New("the link is empty")
+	}
+
+	// @username shorthand
+	if strings.HasPrefix(link, "@") {
+		if !usernamePattern.MatchString(link[1:]) {
+			return "", errors.New("not a valid username")
+		}
+		return "https://t.me/" + link[1:], nil
+	}
+
+	if match := schemePattern.FindStringSubmatch(link); match != nil && !isHostPort(link) {
+		switch scheme := strings.ToLower(match[1]); scheme {
+		case "http", "https":
+		case "tg":
+			parsed, err := url.Parse(link)
+			if err != nil || parsed.Host == "" {
+				return "", errors.New("not a valid tg:// link")
+			}
+			return link, nil
+		default:
+			return "", fmt.Errorf("unsupported scheme %q, use https, tg:// or t.me links", scheme)
+		}
+	} else {
+		link = "https://" + link
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "", errors.New("not a valid link")
+	}
+
+	if parsed.User != nil {
+		return "", errors.New("links with credentials are not allowed")
+	}
+
+	if err = validateHost(parsed.Hostname()); err != nil {
+		return "", err
+	}
+
+	return link, nil
+}
+
+// isHostPort reports whether a link without a scheme starts with host:port, e.g. example.com:8080/path
+func isHostPort(link string) bool {
+	host, port, err := net.SplitHostPort(strings.SplitN(link, "/", 2)[0])
+	if err != nil || host == "" {
+		return false
+	}
+	for _, r := range port {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return strings.Contains(host, ".")
+}
+
+// validateHost checks that a host is an IP address or a domain name with a valid top-level domain
+func validateHost(host string) error {
+	if host == "" {
+		return errors.New("the link has no host")
+	}
+
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	if len(labels) < 2 {
+		return fmt.Errorf("%q is not a valid domain", host)
+	}
+
+	for _, label := range labels {
+		if len(label) > 63 || !hostLabel.MatchString(label) {
+			return fmt.Errorf("%q is not a valid domain", host)
+		}
+	}
+
+	if !tldPattern.MatchString(labels[len(labels)-1]) {
+		return fmt.Errorf("%q is not a valid domain", host)
+	}
+
+	return nil
+}
+
+// ErrPrivateAddress is returned for links to, and connections with, addresses of the server or its network
+var ErrPrivateAddress = errors.New("links to private addresses are not allowed")
+
+// isPrivateIP reports whether ip is an address of the server or its local network
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// PublicTransport is an HTTP transport for URLs given by users, it only connects to public addresses.
+// The address is checked when dialing, so host names that resolve to private addresses and redirects
+// to them are refused too.
+func PublicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+				return ErrPrivateAddress
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would make the connection instead, and could reach the private addresses
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// ValidateCallbackUrl checks that a webhook callback URL is an https link to a public host
+func ValidateCallbackUrl(raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Scheme != "https" {
+		return errors.New("only https links are allowed")
+	}
+
+	if parsed.User != nil {
+		return errors.New("links with credentials are not allowed")
+	}
+
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+		return ErrPrivateAddress
+	}
+	if strings.EqualFold(host, "localhost") {
+		return ErrPrivateAddress
+	}
+
+	return validateHost(host)
+}
@@ -0,0 +1,138 @@
+package helpers
+
+import (
+	"AshokShau/channelManager/src/db"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxDownloadSize is the largest file the Bot API lets bots download
+const maxDownloadSize = 20 << 20
+
+var downloadClient = &http.Client{Timeout: time.Minute, Transport: PublicTransport()}
+
+// DownloadFile downloads a file from Telegram by its file id, feed posts use http(s) URLs instead
+func DownloadFile(b *gotgbot.Bot, fileId string) ([]byte, error) {
+	if IsWebURL(fileId) {
+		return fetchURL(downloadClient, fileId, maxDownloadSize)
+	}
+
+	file, err := b.GetFile(fileId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := downloadClient.Get(file.URL(b, nil))
+	if err != nil {
+		// The file URL contains the bot token, keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed with status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize))
+}
+
+// ApplyWatermark stamps the watermark image on a photo and returns the result as JPEG.
+// Watermarks wider than a quarter of the photo are scaled down to that width.
+func ApplyWatermark(photo, watermark []byte, position string, opacity int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(photo))
+	if err != nil {
+		return nil, fmt.Errorf("decode photo: %w", err)
+	}
+
+	mark, _, err := image.Decode(bytes.NewReader(watermark))
+	if err != nil {
+		return nil, fmt.Errorf("decode watermark: %w", err)
+	}
+
+	bounds := src.Bounds()
+	if maxWidth := bounds.Dx() / 4; mark.Bounds().Dx() > maxWidth && maxWidth > 0 {
+		height := mark.Bounds().Dy() * maxWidth / mark.Bounds().Dx()
+		mark = scaleImage(mark, maxWidth, max(height, 1))
+	}
+
+	margin := min(bounds.Dx(), bounds.Dy()) / 30
+	size := mark.Bounds().Size()
+	var at image.Point
+	switch position {
+	case db.WatermarkTopLeft:
+		at = image.Pt(bounds.Min.X+margin, bounds.Min.Y+margin)
+	case db.WatermarkTopRight:
+		at = image.Pt(bounds.Max.X-size.X-margin, bounds.Min.Y+margin)
+	case db.WatermarkBottomLeft:
+		at = image.Pt(bounds.Min.X+margin, bounds.Max.Y-size.Y-margin)
+	case db.WatermarkCenter:
+		at = image.Pt(bounds.Min.X+(bounds.Dx()-size.X)/2, bounds.Min.Y+(bounds.Dy()-size.Y)/2)
+	default:
+		at = image.Pt(bounds.Max.X-size.X-margin, bounds.Max.Y-size.Y-margin)
+	}
+
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+
+	alpha := image.NewUniform(color.Alpha{A: uint8(min(max(opacity, 1), 100) * 255 / 100)})
+	draw.DrawMask(dst, image.Rectangle{Min: at, Max: at.Add(size)}, mark, mark.Bounds().Min, alpha, image.Point{}, draw.Over)
+
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 92}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleImage downscales an image by averaging the source pixels covered by each target pixel
+func scaleImage(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA64(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					// Weight by alpha so transparent pixels don't darken the edges
+					r += uint64(c.R) * uint64(c.A)
+					g += uint64(c.G) * uint64(c.A)
+					b += uint64(c.B) * uint64(c.A)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			if a == 0 {
+				continue
+			}
+			dst.SetNRGBA64(x, y, color.NRGBA64{
+				R: uint16(r / a),
+				G: uint16(g / a),
+				B: uint16(b / a),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,156 @@
+// Package i18n translates the bot's messages. Catalogs are JSON files in locales/, one per language, that map
+// the English text of a message to its translation. Long texts like the help use a key instead, their English
+// version is in en.json. Messages missing from a catalog are shown in English.
+package i18n
+
+import (
+	"AshokShau/channelManager/src/db"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/PaulSonOfLars/gotgbot/v2"
+)
+
+// Default is the language of messages that have no translation
+const Default = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+var catalogs = make(map[string]map[string]string)
+
+// overrides caches the languages users chose with /lang, "" if they follow their Telegram app, and
+// the languages of their apps seen in updates for messages that are not replies
+var overrides = struct {
+	sync.RWMutex
+	langs map[int64]string
+	seen  map[int64]string
+}{langs: make(map[int64]string), seen: make(map[int64]string)}
+
+func init() {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		log.Fatalf("[i18n] Failed to read the catalogs: %v", err)
+	}
+
+	for _, entry := range entries {
+		data, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			log.Fatalf("[i18n] Failed to read %s: %v", entry.Name(), err)
+		}
+
+		catalog := make(map[string]string)
+		if err = json.Unmarshal(data, &catalog); err != nil {
+			log.Fatalf("[i18n] Invalid catalog %s: %v", entry.Name(), err)
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = catalog
+	}
+}
+
+// Languages returns the codes of the available languages
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Supported reports whether there is a catalog for a language code
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// normalize turns a Telegram language_code like "pt-br" into the code of a catalog, the default if there is none
+func normalize(code string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(code), "-")
+	if Supported(lang) {
+		return lang
+	}
+	return Default
+}
+
+// T translates a message and formats it with args like fmt.Sprintf
+func T(lang, message string, args ...any) string {
+	text, ok := catalogs[lang][message]
+	if !ok || text == "" {
+		if text, ok = catalogs[Default][message]; !ok {
+			text = message
+		}
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// N marks a message for translation where it is translated later, like a reason returned by a check
+func N(message string) string {
+	return message
+}
+
+// override returns the language a user chose with /lang, loading it from the database once
+func override(userId int64) string {
+	overrides.RLock()
+	lang, ok := overrides.langs[userId]
+	overrides.RUnlock()
+	if ok {
+		return lang
+	}
+
+	lang = db.GetUserSettings(userId).Language
+	if !Supported(lang) {
+		lang = ""
+	}
+	overrides.Lock()
+	overrides.langs[userId] = lang
+	overrides.Unlock()
+	return lang
+}
+
+// Lang returns the language of the messages to a user: the one chosen with /lang, else the language of their app
+func Lang(user *gotgbot.User) string {
+	if user == nil {
+		return Default
+	}
+	if lang := override(user.Id); lang != "" {
+		return lang
+	}
+
+	lang := normalize(user.LanguageCode)
+	overrides.Lock()
+	overrides.seen[user.Id] = lang
+	overrides.Unlock()
+	return lang
+}
+
+// UserLang returns the language of messages sent to a user outside of an update, like feed or connection notices
+func UserLang(userId int64) string {
+	if lang := override(userId); lang != "" {
+		return lang
+	}
+
+	overrides.RLock()
+	defer overrides.RUnlock()
+	if lang, ok := overrides.seen[userId]; ok {
+		return lang
+	}
+	return Default
+}
+
+// SetLang stores the language a user chose, "" to follow their app again
+func SetLang(userId int64, lang string) {
+	db.UpdateLanguage(userId, lang)
+	overrides.Lock()
+	overrides.langs[userId] = lang
+	overrides.Unlock()
+}
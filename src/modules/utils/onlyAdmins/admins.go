@@ -2,6 +2,7 @@ package onlyAdmins
 
 import (
 	"AshokShau/channelManager/src/config"
+	"github.com/PaulSonOfLars/gotgbot/v2"
 )
 
 const (
@@ -37,3 +38,66 @@ func IsUserAdmin(chatId, userId int64) (bool, bool) {
 
 	return true, false
 }
+
+// Right is an admin right, named like the field of the chat member that holds it
+type Right string
+
+const (
+	// NotAdmin is returned by MissingRight for users that are not admins at all
+	NotAdmin          Right = "admin"
+	CanPostMessages   Right = "can_post_messages"
+	CanEditMessages   Right = "can_edit_messages"
+	CanDeleteMessages Right = "can_delete_messages"
+	CanPinMessages    Right = "can_pin_messages"
+)
+
+// Admin returns the entry of a user in the admin list
+func (a AdminCache) Admin(userId int64) (gotgbot.MergedChatMember, bool) {
+	for _, admin := range a.UserInfo {
+		if admin.User.Id == userId {
+			return admin, true
+		}
+	}
+	return gotgbot.MergedChatMember{}, false
+}
+
+// MissingRight returns the first of the rights the user lacks in a chat, NotAdmin if the user is not an admin,
+// or "" if they have all of them. Like IsUserAdmin, the first return value tells whether the admin list was cached.
+//
+// The creator has every right. Posting and editing can only be restricted in channels, where pinning needs the
+// right to edit messages.
+func MissingRight(chatId, userId int64, rights ...Right) (bool, Right) {
+	adminsAvail, admins := GetAdminCacheList(chatId)
+	if !admins.Cached || !adminsAvail {
+		return false, NotAdmin
+	}
+
+	admin, ok := admins.Admin(userId)
+	if !ok {
+		return true, NotAdmin
+	}
+	if admin.Status == "creator" {
+		return true, ""
+	}
+
+	channel := GetChatCache(chatId).ChatInfo.Type == "channel"
+	for _, right := range rights {
+		var has bool
+		switch right {
+		case CanPostMessages:
+			has = !channel || admin.CanPostMessages
+		case CanEditMessages:
+			has = !channel || admin.CanEditMessages
+		case CanDeleteMessages:
+			has = admin.CanDeleteMessages
+		case CanPinMessages:
+			has = admin.CanPinMessages || channel && admin.CanEditMessages
+		default:
+			has = true
+		}
+		if !has {
+			return true, right
+		}
+	}
+	return true, ""
+}
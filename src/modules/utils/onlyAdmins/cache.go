@@ -29,9 +29,10 @@ type AdminCache struct {
 }
 
 type ChatCache struct {
-	ChatId   int64
-	ChatInfo gotgbot.Chat
-	Cached   bool
+	ChatId       int64
+	ChatInfo     gotgbot.Chat
+	LinkedChatId int64
+	Cached       bool
 }
 
 var expireTime = 20 * time.Minute
@@ -109,7 +110,7 @@ func LoadChatCache(b *gotgbot.Bot, chatId int64) ChatCache {
 
 	chatInfo := &chat
 
-	err = Marshal.Set(ctx, ChatCache{ChatId: chatId}, ChatCache{ChatId: chatId, ChatInfo: *chatInfo, Cached: true}, store.WithExpiration(expireTime))
+	err = Marshal.Set(ctx, ChatCache{ChatId: chatId}, ChatCache{ChatId: chatId, ChatInfo: *chatInfo, LinkedChatId: fullChat.LinkedChatId, Cached: true}, store.WithExpiration(expireTime))
 	if err != nil {
 		log.Printf("error setting chat info: %v", err)
 		return ChatCache{}
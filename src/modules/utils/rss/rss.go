@@ -0,0 +1,175 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var htmlTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// Item is an article of an RSS or Atom feed
+type Item struct {
+	GUID    string
+	Title   string
+	Link    string
+	Summary string
+	Image   string
+}
+
+// Feed is a parsed RSS or Atom feed, with its items in the order of the document
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+type rssDocument struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			GUID        string `xml:"guid"`
+			Description string `xml:"description"`
+			Enclosures  []struct {
+				Url  string `xml:"url,attr"`
+				Type string `xml:"type,attr"`
+			} `xml:"enclosure"`
+			Media []struct {
+				Url    string `xml:"url,attr"`
+				Medium string `xml:"medium,attr"`
+				Type   string `xml:"type,attr"`
+			} `xml:"http://search.yahoo.com/mrss/ content"`
+			Thumbnails []struct {
+				Url string `xml:"url,attr"`
+			} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDocument struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Id    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+		Summary    string `xml:"summary"`
+		Content    string `xml:"content"`
+		Thumbnails []struct {
+			Url string `xml:"url,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"entry"`
+}
+
+// Parse parses an RSS 2.0 or Atom document
+func Parse(data []byte) (*Feed, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	var root xml.StartElement
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, errors.New("not an RSS or Atom feed")
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+
+	switch root.Name.Local {
+	case "rss":
+		var doc rssDocument
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+
+		feed := &Feed{Title: strings.TrimSpace(doc.Channel.Title)}
+		for _, item := range doc.Channel.Items {
+			feedItem := Item{
+				GUID:    strings.TrimSpace(item.GUID),
+				Title:   strings.TrimSpace(plainText(item.Title)),
+				Link:    strings.TrimSpace(item.Link),
+				Summary: strings.TrimSpace(plainText(item.Description)),
+			}
+
+			for _, enclosure := range item.Enclosures {
+				if strings.HasPrefix(enclosure.Type, "image/") {
+					feedItem.Image = enclosure.Url
+					break
+				}
+			}
+			for _, media := range item.Media {
+				if feedItem.Image == "" && (media.Medium == "image" || strings.HasPrefix(media.Type, "image/")) {
+					feedItem.Image = media.Url
+				}
+			}
+			if feedItem.Image == "" && len(item.Thumbnails) > 0 {
+				feedItem.Image = item.Thumbnails[0].Url
+			}
+
+			feed.Items = append(feed.Items, feedItem.withGUID())
+		}
+		return feed, nil
+	case "feed":
+		var doc atomDocument
+		if err := decoder.DecodeElement(&doc, &root); err != nil {
+			return nil, err
+		}
+
+		feed := &Feed{Title: strings.TrimSpace(doc.Title)}
+		for _, entry := range doc.Entries {
+			feedItem := Item{
+				GUID:    strings.TrimSpace(entry.Id),
+				Title:   strings.TrimSpace(plainText(entry.Title)),
+				Summary: strings.TrimSpace(plainText(entry.Summary)),
+			}
+			if feedItem.Summary == "" {
+				feedItem.Summary = strings.TrimSpace(plainText(entry.Content))
+			}
+
+			for _, link := range entry.Links {
+				switch {
+				case (link.Rel == "" || link.Rel == "alternate") && feedItem.Link == "":
+					feedItem.Link = link.Href
+				case link.Rel == "enclosure" && strings.HasPrefix(link.Type, "image/") && feedItem.Image == "":
+					feedItem.Image = link.Href
+				}
+			}
+			if feedItem.Image == "" && len(entry.Thumbnails) > 0 {
+				feedItem.Image = entry.Thumbnails[0].Url
+			}
+
+			feed.Items = append(feed.Items, feedItem.withGUID())
+		}
+		return feed, nil
+	default:
+		return nil, errors.New("not an RSS or Atom feed")
+	}
+}
+
+// withGUID falls back to the link or title of items without a GUID
+func (item Item) withGUID() Item {
+	if item.GUID == "" {
+		item.GUID = item.Link
+	}
+	if item.GUID == "" {
+		item.GUID = item.Title
+	}
+	return item
+}
+
+// plainText strips the HTML of titles and summaries, feeds often put markup in them
+func plainText(text string) string {
+	return html.UnescapeString(htmlTagRegex.ReplaceAllString(text, ""))
+}
@@ -0,0 +1,99 @@
+package rss
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func parseFixture(t *testing.T, name string) *Feed {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", name, err)
+	}
+	return feed
+}
+
+func checkItems(t *testing.T, got, want []Item) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("item %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseRSS(t *testing.T) {
+	feed := parseFixture(t, "rss.xml")
+	if feed.Title != "Example News" {
+		t.Errorf("title = %q, want %q", feed.Title, "Example News")
+	}
+
+	checkItems(t, feed.Items, []Item{
+		{
+			// Markup in the title and description is stripped, enclosures that are not images are skipped
+			GUID:    "first-guid",
+			Title:   "First story",
+			Link:    "https://example.com/first",
+			Summary: "Tom & Jerry",
+			Image:   "https://example.com/first.jpg",
+		},
+		{
+			// Without a GUID the link identifies the item, media:content images win over thumbnails
+			GUID:    "https://example.com/second",
+			Title:   "Second story",
+			Link:    "https://example.com/second",
+			Summary: "Only media content",
+			Image:   "https://example.com/second.png",
+		},
+		{
+			// Without a GUID and a link the title identifies the item
+			GUID:    "Third story",
+			Title:   "Third story",
+			Summary: "Only a thumbnail",
+			Image:   "https://example.com/third-thumb.jpg",
+		},
+	})
+}
+
+func TestParseAtom(t *testing.T) {
+	feed := parseFixture(t, "atom.xml")
+	if feed.Title != "Example Blog" {
+		t.Errorf("title = %q, want %q", feed.Title, "Example Blog")
+	}
+
+	checkItems(t, feed.Items, []Item{
+		{
+			// The self link is skipped, the summary wins over the content
+			GUID:    "urn:example:entry:1",
+			Title:   "Hello world",
+			Link:    "https://example.com/entries/1",
+			Summary: "The summary",
+			Image:   "https://example.com/1.webp",
+		},
+		{
+			// Without an id the link identifies the entry, the content stands in for a missing summary
+			GUID:    "https://example.com/entries/2",
+			Title:   "Second entry",
+			Link:    "https://example.com/entries/2",
+			Summary: "Only content",
+			Image:   "https://example.com/2-thumb.jpg",
+		},
+	})
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{"", "not xml", "<html><body>page</body></html>"} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", data)
+		}
+	}
+}
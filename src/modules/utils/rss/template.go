@@ -0,0 +1,47 @@
+package rss
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"text/template"
+)
+
+// maxSummary is the number of characters of an item's summary available to templates
+const maxSummary = 600
+
+// DefaultTemplate is used for feeds without a template of their own
+const DefaultTemplate = "<b>{{.Title}}</b>\n\n{{.Summary}}"
+
+// itemData is the data feed templates are executed with, all fields are HTML escaped
+type itemData struct {
+	Title   string
+	Link    string
+	Summary string
+	Feed    string
+}
+
+// ParseTemplate parses the template of a feed, the default one if it is empty
+func ParseTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	return template.New("feed").Option("missingkey=error").Parse(text)
+}
+
+// Render renders a feed item into post text with the feed's template
+func Render(tmpl *template.Template, feedTitle string, item Item) (string, error) {
+	summary := []rune(item.Summary)
+	if len(summary) > maxSummary {
+		summary = append(summary[:maxSummary], '…')
+	}
+
+	var text bytes.Buffer
+	err := tmpl.Execute(&text, itemData{
+		Title:   html.EscapeString(item.Title),
+		Link:    html.EscapeString(item.Link),
+		Summary: html.EscapeString(string(summary)),
+		Feed:    html.EscapeString(feedTitle),
+	})
+	return strings.TrimSpace(text.String()), err
+}
@@ -0,0 +1,85 @@
+package rss
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRenderEscapes(t *testing.T) {
+	tmpl, err := ParseTemplate(`<a href="{{.Link}}">{{.Title}}</a> {{.Feed}}: {{.Summary}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item := Item{Title: "<b>bold</b> & co", Link: `https://example.com/?a=1&b="2"`, Summary: "1 < 2"}
+	text, err := Render(tmpl, "Tom's <feed>", item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<a href="https://example.com/?a=1&amp;b=&#34;2&#34;">&lt;b&gt;bold&lt;/b&gt; &amp; co</a> Tom&#39;s &lt;feed&gt;: 1 &lt; 2`
+	if text != want {
+		t.Errorf("got  %s\nwant %s", text, want)
+	}
+}
+
+func TestRenderDefaultTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := Render(tmpl, "Feed", Item{Title: "Title", Summary: "   "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "<b>Title</b>" {
+		t.Errorf("got %q, want the title with the blank summary trimmed", text)
+	}
+}
+
+func TestRenderTruncatesSummary(t *testing.T) {
+	tmpl, err := ParseTemplate("{{.Summary}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		summary string
+		want    string
+	}{
+		{"short", "short summary", "short summary"},
+		{"at the limit", strings.Repeat("a", maxSummary), strings.Repeat("a", maxSummary)},
+		{"long", strings.Repeat("a", maxSummary+50), strings.Repeat("a", maxSummary) + "…"},
+		// The limit counts characters, multi-byte ones are not cut in half
+		{"multi-byte", strings.Repeat("ж", maxSummary+1), strings.Repeat("ж", maxSummary) + "…"},
+		// Truncation happens before escaping, entities are never cut
+		{"escaped", strings.Repeat("&", maxSummary+1), strings.Repeat("&amp;", maxSummary) + "…"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := Render(tmpl, "", Item{Summary: tc.summary})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != tc.want {
+				t.Errorf("got %d characters, want %d", utf8.RuneCountInString(text), utf8.RuneCountInString(tc.want))
+			}
+		})
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	if _, err := ParseTemplate("{{.Title"); err == nil {
+		t.Error("unclosed action parsed")
+	}
+
+	tmpl, err := ParseTemplate("{{.Unknown}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Render(tmpl, "", Item{}); err == nil {
+		t.Error("unknown field rendered")
+	}
+}
@@ -0,0 +1,155 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"bytes"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"image"
+	"strconv"
+	"strings"
+)
+
+var watermarkPositions = map[string]string{
+	"tl": db.WatermarkTopLeft, db.WatermarkTopLeft: db.WatermarkTopLeft,
+	"tr": db.WatermarkTopRight, db.WatermarkTopRight: db.WatermarkTopRight,
+	"bl": db.WatermarkBottomLeft, db.WatermarkBottomLeft: db.WatermarkBottomLeft,
+	"br": db.WatermarkBottomRight, db.WatermarkBottomRight: db.WatermarkBottomRight,
+	"center": db.WatermarkCenter,
+}
+
+// watermarkCache holds the file ids of the photos watermarked during one delivery,
+// so each photo is rendered and uploaded once per watermark instead of once per chat.
+type watermarkCache map[string]string
+
+func watermarkKey(fileId string, mark *db.Watermark) string {
+	return fmt.Sprintf("%s:%s:%s:%d", fileId, mark.FileId, mark.Position, mark.Opacity)
+}
+
+// photo returns the watermarked photo to send: the file id of an earlier upload in this delivery,
+// or the rendered image, which should be passed to store once it was sent.
+func (marks watermarkCache) photo(b *gotgbot.Bot, fileId string, mark *db.Watermark) (gotgbot.InputFileOrString, error) {
+	if id, ok := marks[watermarkKey(fileId, mark)]; ok {
+		return gotgbot.InputFileByID(id), nil
+	}
+
+	photo, err := helpers.DownloadFile(b, fileId)
+	if err != nil {
+		return nil, fmt.Errorf("watermark: download photo: %w", err)
+	}
+
+	watermark, err := helpers.DownloadFile(b, mark.FileId)
+	if err != nil {
+		return nil, fmt.Errorf("watermark: download watermark: %w", err)
+	}
+
+	data, err := helpers.ApplyWatermark(photo, watermark, mark.Position, mark.Opacity)
+	if err != nil {
+		return nil, fmt.Errorf("watermark: %w", err)
+	}
+	return gotgbot.InputFileByReader("photo.jpg", bytes.NewReader(data)), nil
+}
+
+// store remembers the uploaded watermarked photo of a sent message
+func (marks watermarkCache) store(fileId string, mark *db.Watermark, message *gotgbot.Message) {
+	if marks == nil || message == nil || len(message.Photo) == 0 {
+		return
+	}
+	marks[watermarkKey(fileId, mark)] = message.Photo[len(message.Photo)-1].FileId
+}
+
+func setWatermark(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 1 {
+		text := tr(ctx, "Reply to a photo or PNG file to stamp it on photo posts sent to a chat.\n"+
+			"Usage: <code>!watermark chat_id [position] [opacity]</code>\n\n"+
+			"Positions: <code>tl</code>, <code>tr</code>, <code>bl</code>, <code>br</code> (default), <code>center</code>\n"+
+			"Opacity: 1-100 percent, default 50\n"+
+			"Use <code>!watermark chat_id off</code> to remove it.")
+		_, err := msg.Reply(b, text, helpers.Shtml())
+		return err
+	}
+
+	chatId, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || !helpers.Contains(db.Connection(msg.From.Id).ChatIds, chatId) {
+		_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is not one of your connected chats.", args[0]), helpers.Shtml())
+		return err
+	}
+
+	if len(args) > 1 && args[1] == "off" {
+		if err = db.SetChatWatermark(msg.From.Id, chatId, nil); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the watermark."), helpers.Shtml())
+			return err
+		}
+		_, err = msg.Reply(b, tr(ctx, "Watermark removed."), helpers.Shtml())
+		return err
+	}
+
+	reply := msg.ReplyToMessage
+	if reply == nil {
+		chatSetting := db.GetChatSettings(msg.From.Id, chatId)
+		if chatSetting.Watermark == nil {
+			_, err = msg.Reply(b, tr(ctx, "This chat has no watermark. Reply to a photo or PNG file to set one."), helpers.Shtml())
+			return err
+		}
+
+		mark := chatSetting.Watermark
+		caption := tr(ctx, "Watermark of <code>%d</code>\nPosition: %s\nOpacity: %d%%", chatId, mark.Position, mark.Opacity)
+		_, err = b.SendPhoto(msg.Chat.Id, gotgbot.InputFileByID(mark.FileId), &gotgbot.SendPhotoOpts{Caption: caption, ParseMode: gotgbot.ParseModeHTML})
+		if err != nil {
+			// Watermarks set from a PNG file can't be sent as photo
+			_, err = b.SendDocument(msg.Chat.Id, gotgbot.InputFileByID(mark.FileId), &gotgbot.SendDocumentOpts{Caption: caption, ParseMode: gotgbot.ParseModeHTML})
+		}
+		return err
+	}
+
+	mark := &db.Watermark{Position: db.WatermarkBottomRight, Opacity: 50}
+	switch {
+	case len(reply.Photo) > 0:
+		mark.FileId = reply.Photo[len(reply.Photo)-1].FileId
+	case reply.Document != nil && reply.Document.MimeType == "image/png":
+		mark.FileId = reply.Document.FileId
+	default:
+		_, err = msg.Reply(b, tr(ctx, "Please reply to a photo or a PNG file. Send the logo as a file to keep its transparency."), helpers.Shtml())
+		return err
+	}
+
+	for _, arg := range args[1:] {
+		if position, ok := watermarkPositions[strings.ToLower(arg)]; ok {
+			mark.Position = position
+			continue
+		}
+
+		opacity, err := strconv.Atoi(strings.TrimSuffix(arg, "%"))
+		if err != nil || opacity < 1 || opacity > 100 {
+			_, err = msg.Reply(b, tr(ctx, "<code>%s</code> is neither a position nor an opacity between 1 and 100.", arg), helpers.Shtml())
+			return err
+		}
+		mark.Opacity = opacity
+	}
+
+	// Make sure the watermark can be decoded before saving it
+	data, err := helpers.DownloadFile(b, mark.FileId)
+	if err == nil {
+		_, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error reading the watermark image.\n\n<code>")+err.Error()+"</code>", helpers.Shtml())
+		return nil
+	}
+
+	if err = db.SetChatWatermark(msg.From.Id, chatId, mark); err != nil {
+		_, _ = msg.Reply(b, tr(ctx, "Error saving the watermark."), helpers.Shtml())
+		return err
+	}
+
+	_, err = msg.Reply(b, tr(ctx, "Watermark saved for <code>%d</code> (%s, %d%% opacity).\nIt will be stamped on photo posts sent to this chat.", chatId, mark.Position, mark.Opacity), helpers.Shtml())
+	return err
+}
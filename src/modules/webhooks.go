@@ -0,0 +1,272 @@
+package modules
+
+import (
+	"AshokShau/channelManager/src/db"
+	"AshokShau/channelManager/src/modules/utils/helpers"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events sent to webhooks
+const (
+	eventPostSent       = "post.sent"
+	eventPostFailed     = "post.failed"
+	eventPostDeleted    = "post.deleted"
+	eventConnectionLost = "connection.lost"
+)
+
+var webhookEvents = []string{eventPostSent, eventPostFailed, eventPostDeleted, eventConnectionLost}
+
+const (
+	// maxWebhooks is the number of webhooks a user can register
+	maxWebhooks = 5
+	// webhookAttempts is how often an event is sent before it is dropped, the delay doubles after each attempt
+	webhookAttempts = 6
+	webhookBackoff  = 2 * time.Second
+)
+
+var webhookClient = &http.Client{
+	Timeout:   10 * time.Second,
+	Transport: helpers.PublicTransport(),
+	// Redirects could lead the signed events anywhere, they count as failed attempts
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// webhookEvent is the JSON body of an event
+type webhookEvent struct {
+	Id      string `json:"id"`
+	Event   string `json:"event"`
+	Created int64  `json:"created"`
+	Data    any    `json:"data"`
+}
+
+// emitEvent sends an event to the webhooks of a user that subscribed to it. Events are sent in the
+// background and retried with exponential backoff, pending retries are lost when the bot restarts.
+func emitEvent(userId int64, event string, data map[string]any) {
+	webhooks, err := db.ListWebhooks(userId)
+	if err != nil || len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(webhookEvent{Id: helpers.GenerateUniqueString(), Event: event, Created: time.Now().Unix(), Data: data})
+	if err != nil {
+		log.Printf("[webhook] Failed to encode %s event: %v", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if len(webhook.Events) > 0 && !slices.Contains(webhook.Events, event) {
+			continue
+		}
+		go sendWebhook(webhook, event, body)
+	}
+}
+
+// signWebhook returns the signature header of an event body, an HMAC-SHA256 of "timestamp.body"
+func signWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhook posts an event to a webhook, retrying failed attempts
+func sendWebhook(webhook db.Webhook, event string, body []byte) {
+	delay := webhookBackoff
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		retry, err := postWebhook(webhook, event, body)
+		if err == nil {
+			return
+		}
+		if !retry {
+			log.Printf("[webhook] %s to %s failed: %v", event, webhook.Url, err)
+			return
+		}
+		if attempt < webhookAttempts {
+			time.Sleep(delay)
+			delay *= 2
+		} else {
+			log.Printf("[webhook] %s to %s failed after %d attempts: %v", event, webhook.Url, attempt, err)
+		}
+	}
+}
+
+// postWebhook makes one attempt to deliver an event and reports whether a failure is worth retrying
+func postWebhook(webhook db.Webhook, event string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ChannelManagerBot/1.0")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", signWebhook(webhook.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		// The host resolved to the server or its network, retrying won't change that
+		if errors.Is(err, helpers.ErrPrivateAddress) {
+			return false, helpers.ErrPrivateAddress
+		}
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 || (resp.StatusCode >= 300 && resp.StatusCode < 400):
+		return true, fmt.Errorf("status %s", resp.Status)
+	default:
+		return false, fmt.Errorf("status %s", resp.Status)
+	}
+}
+
+// emitSent sends the post.sent event of a delivered message
+func emitSent(userId int64, postId string, message *gotgbot.Message) {
+	emitEvent(userId, eventPostSent, map[string]any{
+		"post_id":    postId,
+		"chat_id":    message.Chat.Id,
+		"message_id": message.MessageId,
+		"link":       message.GetLink(),
+	})
+}
+
+// emitFailed sends the post.failed event of a post that could not be sent to a chat
+func emitFailed(userId int64, postId string, chatId int64, err error) {
+	emitEvent(userId, eventPostFailed, map[string]any{
+		"post_id": postId,
+		"chat_id": chatId,
+		"error":   err.Error(),
+	})
+}
+
+// emitDeleted sends the post.deleted event of a deleted post message
+func emitDeleted(userId int64, postId string, chatId, msgId int64) {
+	emitEvent(userId, eventPostDeleted, map[string]any{
+		"post_id":    postId,
+		"chat_id":    chatId,
+		"message_id": msgId,
+	})
+}
+
+func setWebhook(b *gotgbot.Bot, ctx *ext.Context) error {
+	msg := ctx.EffectiveMessage
+	if msg.Chat.Type != "private" {
+		return nil
+	}
+
+	args := ctx.Args()[1:]
+	if len(args) < 2 {
+		webhooks, _ := db.ListWebhooks(msg.From.Id)
+		var text strings.Builder
+		text.WriteString(tr(ctx, "Webhooks receive signed JSON events when your posts are sent, fail or are deleted, and when a chat connection is lost.\n"+
+			"Usage: <code>!webhook add https_url [events]</code>\n"+
+			"Events: <code>%s</code>, all if none are given.\n"+
+			"Use <code>!webhook test webhook_id</code> to send a test event and <code>!webhook remove webhook_id</code> to remove it.\n\n"+
+			"Each event has an <code>X-Webhook-Signature</code> header: <code>sha256=</code> and the hex HMAC-SHA256 of "+
+			"<code>timestamp.body</code> with your secret, the timestamp is in <code>X-Webhook-Timestamp</code>.\n", strings.Join(webhookEvents, "</code>, <code>")))
+
+		if len(webhooks) > 0 {
+			text.WriteString(tr(ctx, "\n<b>Your webhooks:</b>\n"))
+			for _, webhook := range webhooks {
+				events := tr(ctx, "all events")
+				if len(webhook.Events) > 0 {
+					events = strings.Join(webhook.Events, ", ")
+				}
+				text.WriteString(fmt.Sprintf("<code>%s</code> - %s (%s)\n", webhook.WebhookId, html.EscapeString(webhook.Url), events))
+			}
+		}
+
+		_, err := msg.Reply(b, text.String(), helpers.Shtml())
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		webhookUrl := strings.TrimSpace(args[1])
+		if err := helpers.ValidateCallbackUrl(webhookUrl); err != nil {
+			_, err = msg.Reply(b, tr(ctx, "Invalid webhook URL: %s", html.EscapeString(err.Error())), helpers.Shtml())
+			return err
+		}
+
+		for _, event := range args[2:] {
+			if !slices.Contains(webhookEvents, event) {
+				_, err := msg.Reply(b, tr(ctx, "Unknown event <code>%s</code>.", html.EscapeString(event)), helpers.Shtml())
+				return err
+			}
+		}
+
+		if webhooks, _ := db.ListWebhooks(msg.From.Id); len(webhooks) >= maxWebhooks {
+			_, err := msg.Reply(b, tr(ctx, "You can register up to %d webhooks.", maxWebhooks), helpers.Shtml())
+			return err
+		}
+
+		raw := make([]byte, 24)
+		if _, err := rand.Read(raw); err != nil {
+			return err
+		}
+		webhook := db.Webhook{
+			WebhookId: helpers.GenerateUniqueString(),
+			UserId:    msg.From.Id,
+			Url:       webhookUrl,
+			Secret:    "whsec_" + hex.EncodeToString(raw),
+			Events:    args[2:],
+		}
+		if err := db.AddWebhook(webhook); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error saving the webhook."), helpers.Shtml())
+			return err
+		}
+
+		text := tr(ctx, "Webhook added.\n\n<b>WebhookId:</b> <code>%s</code>\n<b>Secret:</b> <code>%s</code>\n\n"+
+			"Keep the secret to verify the signatures of the events, it is shown only once.", webhook.WebhookId, webhook.Secret)
+		_, err := msg.Reply(b, text, helpers.Shtml())
+		return err
+	case "remove", "off", "test":
+		webhook, err := db.GetWebhook(args[1])
+		if err != nil || webhook == nil || webhook.UserId != msg.From.Id {
+			_, _ = msg.Reply(b, tr(ctx, "Webhook not found."), helpers.Shtml())
+			return err
+		}
+
+		if args[0] == "test" {
+			body, _ := json.Marshal(webhookEvent{Id: helpers.GenerateUniqueString(), Event: "ping", Created: time.Now().Unix(), Data: map[string]any{}})
+			text := tr(ctx, "✅ The test event was delivered.")
+			if _, err = postWebhook(*webhook, "ping", body); err != nil {
+				text = tr(ctx, "❌ The test event failed: %s", html.EscapeString(err.Error()))
+			}
+			_, err = msg.Reply(b, text, helpers.Shtml())
+			return err
+		}
+
+		if err = db.RemoveWebhook(webhook.WebhookId); err != nil {
+			_, _ = msg.Reply(b, tr(ctx, "Error removing the webhook."), helpers.Shtml())
+			return err
+		}
+		_, err = msg.Reply(b, tr(ctx, "Webhook removed."), helpers.Shtml())
+		return err
+	default:
+		_, err := msg.Reply(b, tr(ctx, "Please use <code>!webhook add</code>, <code>test</code> or <code>remove</code>."), helpers.Shtml())
+		return err
+	}
+}
@@ -0,0 +1,28 @@
+package modules
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"time"
+)
+
+// StartWorkers starts the background jobs of the bot
+func StartWorkers(b *gotgbot.Bot) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runUnpins(b)
+			runSchedules(b)
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(feedInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			runFeeds(b)
+		}
+	}()
+}